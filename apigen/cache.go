@@ -0,0 +1,205 @@
+package apigen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	cacheFile    = ".apigen-cache.json"
+	cacheVersion = 1
+)
+
+var (
+	executableHash     string
+	executableHashOnce sync.Once
+)
+
+// generatorVersion identifies the build of apigen that is running, so that
+// cached outputs are regenerated whenever the generator itself changes.
+func generatorVersion() string {
+	executableHashOnce.Do(func() {
+		filename, err := os.Executable()
+		if err != nil {
+			return
+		}
+
+		f, err := os.Open(filename)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return
+		}
+
+		executableHash = hex.EncodeToString(h.Sum(nil))
+	})
+
+	return executableHash
+}
+
+// writerInputHash summarises everything that goes into the output of a
+// writer, including the settings of the config it's run with. It's empty if
+// the writer's output can't be cached.
+func writerInputHash(w writer, disableFormatting bool, settingsHash string) string {
+	hw, ok := w.(writerWithHash)
+	if !ok || hw.Hash() == "" {
+		return ""
+	}
+
+	version := generatorVersion()
+	if version == "" {
+		return ""
+	}
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%d\n%s\n%s\n%s\n%s\n%v\n%s\n", cacheVersion, version, hw.Hash(), w.Language(), w.File(), disableFormatting, settingsHash)
+
+	if w, ok := w.(writerForGo); ok {
+		fmt.Fprintf(h, "%s\n%s\n", w.PackageName(), strings.Join(w.Imports(), "\n"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func outputHash(d []byte) string {
+	h := sha256.Sum256(d)
+	return hex.EncodeToString(h[:])
+}
+
+type cacheEntry struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// cache records the input and output hashes of each file generated into an
+// output directory, so that writers can be skipped when neither their input
+// nor the file on disk has changed since they last ran.
+type cache struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+
+	root string
+	m    sync.Mutex
+}
+
+func loadCache(root string) (*cache, error) {
+	c := cache{Version: cacheVersion, Entries: make(map[string]cacheEntry), root: root}
+
+	d, err := ioutil.ReadFile(filepath.Join(root, cacheFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &c, nil
+		}
+
+		return nil, fmt.Errorf("loadCache: couldn't read cache: %w", err)
+	}
+
+	var stored cache
+	if err := json.Unmarshal(d, &stored); err != nil || stored.Version != cacheVersion {
+		// an unreadable or outdated cache just means everything gets
+		// generated again
+		return &c, nil
+	}
+
+	for k, v := range stored.Entries {
+		c.Entries[k] = v
+	}
+
+	return &c, nil
+}
+
+func (c *cache) key(filename string) string {
+	if rel, err := filepath.Rel(c.root, filename); err == nil {
+		return filepath.ToSlash(rel)
+	}
+
+	return filename
+}
+
+// fresh reports whether filename was last generated from the same input and
+// hasn't been changed on disk since.
+func (c *cache) fresh(filename, input string) bool {
+	if input == "" {
+		return false
+	}
+
+	c.m.Lock()
+	e, ok := c.Entries[c.key(filename)]
+	c.m.Unlock()
+
+	if !ok || e.Input != input {
+		return false
+	}
+
+	d, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+
+	return outputHash(d) == e.Output
+}
+
+func (c *cache) record(filename, input string, output []byte) {
+	if input == "" {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.Entries[c.key(filename)] = cacheEntry{Input: input, Output: outputHash(output)}
+}
+
+// forget drops the entry for filename, so that it's generated again next time.
+func (c *cache) forget(filename string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	delete(c.Entries, c.key(filename))
+}
+
+// save writes the cache, forgetting any files that weren't produced in this
+// run.
+func (c *cache) save(produced []string) error {
+	keep := make(map[string]bool)
+	for _, e := range produced {
+		keep[c.key(e)] = true
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for k := range c.Entries {
+		if !keep[k] {
+			delete(c.Entries, k)
+		}
+	}
+
+	d, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cache.save: couldn't encode cache: %w", err)
+	}
+
+	if err := os.MkdirAll(c.root, 0755); err != nil {
+		return fmt.Errorf("cache.save: couldn't prepare directory: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(c.root, cacheFile), append(d, '\n'), 0644); err != nil {
+		return fmt.Errorf("cache.save: couldn't write cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package apigen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterInputHash(t *testing.T) {
+	w := func(hash string, imports ...string) writer {
+		return &basicWriterForGo{
+			basicWriter: basicWriter{name: "individual", language: "go", file: "models/widget_api.go", hash: hash},
+			packageName: "models",
+			imports:     imports,
+		}
+	}
+
+	base := writerInputHash(w("a", "fmt"), false, "s")
+	if !assert.NotEmpty(t, base) {
+		return
+	}
+
+	assert.Equal(t, base, writerInputHash(w("a", "fmt"), false, "s"))
+
+	for name, other := range map[string]string{
+		"template and model": writerInputHash(w("b", "fmt"), false, "s"),
+		"imports":            writerInputHash(w("a", "fmt", "time"), false, "s"),
+		"formatting":         writerInputHash(w("a", "fmt"), true, "s"),
+		"settings":           writerInputHash(w("a", "fmt"), false, "t"),
+	} {
+		assert.NotEqual(t, base, other, name)
+	}
+
+	// writers that can't summarise their input are never cached
+	assert.Equal(t, "", writerInputHash(w("", "fmt"), false, "s"))
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "sub", "b.go")
+
+	mustNoError(t, os.MkdirAll(filepath.Dir(b), 0755))
+	mustNoError(t, ioutil.WriteFile(a, []byte("a"), 0644))
+	mustNoError(t, ioutil.WriteFile(b, []byte("b"), 0644))
+
+	c, err := loadCache(dir)
+	mustNoError(t, err)
+
+	assert.False(t, c.fresh(a, "1"), "nothing is fresh in an empty cache")
+
+	c.record(a, "1", []byte("a"))
+	c.record(b, "2", []byte("b"))
+
+	assert.True(t, c.fresh(a, "1"))
+	assert.True(t, c.fresh(b, "2"))
+	assert.False(t, c.fresh(a, "2"), "a different input isn't fresh")
+	assert.False(t, c.fresh(a, ""), "an empty input is never fresh")
+
+	// only the files produced in the run are kept
+	mustNoError(t, c.save([]string{a}))
+
+	c, err = loadCache(dir)
+	mustNoError(t, err)
+
+	assert.Equal(t, map[string]cacheEntry{"a.go": {Input: "1", Output: outputHash([]byte("a"))}}, c.Entries)
+	assert.True(t, c.fresh(a, "1"))
+	assert.False(t, c.fresh(b, "2"))
+
+	// a file that's been changed on disk, or removed, has to be generated
+	// again
+	mustNoError(t, ioutil.WriteFile(a, []byte("edited"), 0644))
+	assert.False(t, c.fresh(a, "1"))
+
+	mustNoError(t, os.Remove(a))
+	assert.False(t, c.fresh(a, "1"))
+
+	c.record(a, "1", []byte("a"))
+	c.forget(a)
+	assert.Empty(t, c.Entries)
+
+	// a cache that can't be read is treated as empty
+	mustNoError(t, ioutil.WriteFile(filepath.Join(dir, cacheFile), []byte("{"), 0644))
+
+	c, err = loadCache(dir)
+	mustNoError(t, err)
+	assert.Empty(t, c.Entries)
+}
@@ -0,0 +1,237 @@
+package apigen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are the names searched for in the working directory when no
+// config file is given explicitly.
+var configFiles = []string{"apigen.yaml", "apigen.yml", "apigen.json"}
+
+// Config describes the conventions of the project that code is being
+// generated for. Anything left empty falls back to the conventions of the
+// project apigen was originally written for.
+type Config struct {
+	// PackageName is the name of the package that model code is written to.
+	// The default is the name of the package the models were loaded from.
+	PackageName string `json:"package_name" yaml:"package_name"`
+	// ModelsImportPath is the import path of the models package, used to
+	// import it and the modelapifilter, modelenum, and modelschema packages
+	// beneath it. The default is the import path of the loaded package.
+	ModelsImportPath string `json:"models_import_path" yaml:"models_import_path"`
+	// InternalImportPath is the import path that runtime support packages
+	// (apifilter, apitypes, changeregistry, etc) are found under.
+	InternalImportPath string `json:"internal_import_path" yaml:"internal_import_path"`
+	// Imports overrides the import path of individual runtime support
+	// packages, keyed by package name.
+	Imports map[string]string `json:"imports" yaml:"imports"`
+	// JSDir and FlowDir are the default JavaScript and Flow output
+	// directories, relative to the Go output directory unless absolute.
+	JSDir   string `json:"js_dir" yaml:"js_dir"`
+	FlowDir string `json:"flow_dir" yaml:"flow_dir"`
+	// UpperCaseOverrides lists the acronyms that are kept upper case when
+	// converting between naming conventions. If set, it replaces the
+	// built-in list.
+	UpperCaseOverrides []string `json:"upper_case_overrides" yaml:"upper_case_overrides"`
+	// IgnoreCreate and IgnoreUpdate list the API field names that are never
+	// set from user input when creating or updating records. If set, they
+	// replace the built-in lists.
+	IgnoreCreate []string `json:"ignore_create" yaml:"ignore_create"`
+	IgnoreUpdate []string `json:"ignore_update" yaml:"ignore_update"`
+	// TypedEnums generates a type for each string enum field of every model,
+	// as "@apigen typedenums" does for a single model.
+	TypedEnums bool `json:"typed_enums" yaml:"typed_enums"`
+	// Types adds to the registry of Go types that apigen knows how to
+	// represent, keyed by their package qualified name, e.g. decimal.Decimal.
+	// Anything set for a type that's already known, including the built-in
+	// ones, replaces that part of its mapping. Named types with a basic
+	// underlying type only need to be listed to represent them differently
+	// from that type; any other type has to set at least js, flow, sql, and
+	// swagger, and implement sql.Scanner, driver.Valuer, and json.Marshaler
+	// (or encoding.TextMarshaler).
+	Types map[string]TypeMapping `json:"types" yaml:"types"`
+	// TemplateDir is a directory of templates that replace the built-in
+	// ones, named after the generator and writer, e.g. api/individual.tmpl.
+	// In a config file, it's relative to the file unless absolute.
+	TemplateDir string `json:"template_dir" yaml:"template_dir"`
+	// Plugins are external programs that generate extra code from the models,
+	// run after the built-in generators.
+	Plugins []PluginConfig `json:"plugins" yaml:"plugins"`
+
+	// The rest of the fields describe a single run, so they can't be set from
+	// a config file.
+
+	// File is the config file that the rest of the config was loaded from,
+	// if any. Watch polls it for changes.
+	File string `json:"-" yaml:"-"`
+	// Patterns are the packages to load models from, in the form accepted by
+	// go list.
+	Patterns []string `json:"-" yaml:"-"`
+	// Dir is the directory that packages are loaded from. The default is the
+	// working directory.
+	Dir string `json:"-" yaml:"-"`
+	// GoDir is the directory that model code is written to. The default is
+	// the directory the models were loaded from.
+	GoDir string `json:"-" yaml:"-"`
+	// Filters restricts the writers that are run.
+	Filters WriterFilterList `json:"-" yaml:"-"`
+	// Dry stops anything from being written.
+	Dry bool `json:"-" yaml:"-"`
+	// Check compares the generated code with what's on disk instead of
+	// writing it. Files that differ are listed in Result.OutOfDate.
+	Check bool `json:"-" yaml:"-"`
+	// Prune deletes generated files that are no longer produced by any
+	// writer.
+	Prune bool `json:"-" yaml:"-"`
+	// Force runs all writers, even those whose output is known to be up to
+	// date.
+	Force bool `json:"-" yaml:"-"`
+	// DisableFormatting skips formatting the generated Go code.
+	DisableFormatting bool `json:"-" yaml:"-"`
+	// AllowSourceErrors carries on when the source packages have errors.
+	AllowSourceErrors bool `json:"-" yaml:"-"`
+	// Jobs is the number of writers to run concurrently. The default is the
+	// number of CPUs.
+	Jobs int `json:"-" yaml:"-"`
+	// Logger receives progress and problems. The default is the logrus
+	// standard logger.
+	Logger *logrus.Logger `json:"-" yaml:"-"`
+	// Diffs receives a diff of each file that's out of date when checking.
+	Diffs io.Writer `json:"-" yaml:"-"`
+	// Progress receives a count of the source files as they're parsed.
+	Progress io.Writer `json:"-" yaml:"-"`
+
+	// settings are made from the rest of the config by newRun.
+	settings *settings
+}
+
+func defaultConfig() Config {
+	return Config{
+		InternalImportPath: "movingdata.com/p/wbi/internal",
+		JSDir:              "../client/src",
+		FlowDir:            "../static/flow/lib",
+	}
+}
+
+// LoadConfig reads the config file at filename, or if filename is empty, the
+// first of configFiles found in the working directory. Having no config file
+// at all is fine, and results in the default config.
+func LoadConfig(filename string) (*Config, string, error) {
+	cfg := defaultConfig()
+
+	if filename == "" {
+		for _, e := range configFiles {
+			if _, err := os.Stat(e); err == nil {
+				filename = e
+				break
+			}
+		}
+	}
+
+	if filename == "" {
+		return &cfg, "", nil
+	}
+
+	d, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("LoadConfig: couldn't read %s: %w", filename, err)
+	}
+
+	switch filepath.Ext(filename) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(d))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, "", fmt.Errorf("LoadConfig: couldn't parse %s: %w", filename, err)
+		}
+	default:
+		dec := yaml.NewDecoder(bytes.NewReader(d))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, "", fmt.Errorf("LoadConfig: couldn't parse %s: %w", filename, err)
+		}
+	}
+
+	// relative paths in the config file are relative to the file, rather than
+	// to wherever apigen happens to be run from
+	base := filepath.Dir(filename)
+
+	if cfg.TemplateDir != "" && !filepath.IsAbs(cfg.TemplateDir) {
+		cfg.TemplateDir = filepath.Join(base, cfg.TemplateDir)
+	}
+
+	names := make(map[string]bool)
+	for i, e := range cfg.Plugins {
+		if e.Name == "" {
+			return nil, "", fmt.Errorf("LoadConfig: %s: plugins need a name", filename)
+		}
+		if names[e.Name] {
+			return nil, "", fmt.Errorf("LoadConfig: %s: plugin %q is defined more than once", filename, e.Name)
+		}
+		if len(e.Command) == 0 {
+			return nil, "", fmt.Errorf("LoadConfig: %s: plugin %q needs a command", filename, e.Name)
+		}
+
+		// commands without a directory are looked up in the PATH
+		if cmd := e.Command[0]; !filepath.IsAbs(cmd) && strings.ContainsRune(filepath.ToSlash(cmd), '/') {
+			cfg.Plugins[i].Command[0] = filepath.Join(base, cmd)
+		}
+
+		names[e.Name] = true
+	}
+
+	cfg.File = filename
+
+	return &cfg, filename, nil
+}
+
+// forPackage returns a copy of the config with the package specific defaults
+// filled in.
+func (c *Config) forPackage(packageName, importPath string) *Config {
+	cfg := *c
+
+	def := defaultConfig()
+	if cfg.InternalImportPath == "" {
+		cfg.InternalImportPath = def.InternalImportPath
+	}
+	if cfg.JSDir == "" {
+		cfg.JSDir = def.JSDir
+	}
+	if cfg.FlowDir == "" {
+		cfg.FlowDir = def.FlowDir
+	}
+	if cfg.PackageName == "" {
+		cfg.PackageName = packageName
+	}
+	if cfg.ModelsImportPath == "" {
+		cfg.ModelsImportPath = importPath
+	}
+
+	return &cfg
+}
+
+// internalImport returns the import path of the named runtime support package.
+func (c *Config) internalImport(name string) string {
+	if s, ok := c.Imports[name]; ok {
+		return s
+	}
+
+	return path.Join(c.InternalImportPath, name)
+}
+
+// modelsImport returns the import path of a package within the models
+// package.
+func (c *Config) modelsImport(elem ...string) string {
+	return path.Join(append([]string{c.ModelsImportPath}, elem...)...)
+}
@@ -0,0 +1,37 @@
+package apigen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+
+	mustNoError(t, os.MkdirAll(filepath.Join(dir, "config"), 0755))
+	mustNoError(t, ioutil.WriteFile(filepath.Join(dir, "config", "apigen.yaml"), []byte(`template_dir: templates
+plugins:
+  - name: local
+    command: [./tools/plugin, --flag]
+  - name: installed
+    command: [apigen-plugin]
+  - name: absolute
+    command: [/usr/bin/plugin]
+`), 0644))
+
+	// paths in the file are resolved against its directory, no matter where
+	// it's loaded from
+	cfg, _, err := LoadConfig(filepath.Join(dir, "config", "apigen.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, filepath.Join(dir, "config", "templates"), cfg.TemplateDir)
+	assert.Equal(t, []string{filepath.Join(dir, "config", "tools", "plugin"), "--flag"}, cfg.Plugins[0].Command)
+	assert.Equal(t, []string{"apigen-plugin"}, cfg.Plugins[1].Command)
+	assert.Equal(t, []string{"/usr/bin/plugin"}, cfg.Plugins[2].Command)
+}
@@ -0,0 +1,143 @@
+package apigen
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Default is the value that a field's default tag gives it when it's
+// created without one, e.g. `default:"pending"`, `default:"now"`, or
+// `default:"uuid"`.
+type Default struct {
+	// Kind is "now" or "uuid" for values that are worked out when the
+	// model is created, or "literal" for the rest, including enum values.
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	// Go is an expression for the value, and Zero an expression that's true
+	// if $1 doesn't have a value yet. Pointer is set if the field holds a
+	// pointer to the value.
+	Go      string `json:"go"`
+	Zero    string `json:"zero"`
+	Pointer bool   `json:"pointer,omitempty"`
+}
+
+// numberPattern is the decimal grammar that float and decimal defaults are
+// written in. It leaves out the special values, hex, and underscores that
+// strconv accepts, so that the tag can also be used as is in JS.
+var numberPattern = regexp.MustCompile(`^-?(\d+(\.\d+)?|\.\d+)([eE][+-]?\d+)?$`)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// parseDefault parses a default tag for a field whose values are represented
+// as mapType, and described by mapping. If the field has a named type, goType
+// is what values are converted to.
+func parseDefault(tag, mapType, goType string, mapping TypeMapping, isSlice, isPointer bool, enums EnumList) (*Default, error) {
+	if isSlice {
+		return nil, fmt.Errorf("slices can't have a default")
+	}
+
+	// a bool that's false can't be told apart from one that hasn't been set,
+	// so only pointers to bools can have a default
+	if mapType == "bool" && !isPointer {
+		return nil, fmt.Errorf("bool fields can't have a default, as false can't be told apart from no value; use a *bool instead")
+	}
+
+	d := Default{Kind: "literal", Value: tag, Zero: mapping.Zero, Pointer: isPointer}
+	if isPointer {
+		d.Zero = "$1 == nil"
+	}
+	if d.Zero == "" {
+		return nil, fmt.Errorf("%s fields can't have a default, as they don't have a zero value apigen knows about", mapType)
+	}
+
+	if len(enums) > 0 && enums.GetByValue(tag) == nil {
+		return nil, fmt.Errorf("%q isn't one of the enum values", tag)
+	}
+
+	// untyped constants have to be converted to be pointed to, e.g. to an
+	// int32 rather than an int
+	untyped := false
+
+	switch {
+	case tag == "now" && mapType == "time.Time":
+		d.Kind, d.Go = "now", "now"
+	case tag == "uuid" && mapType == "uuid.UUID":
+		d.Kind, d.Go = "uuid", "uuid.Must(uuid.NewV4())"
+	case mapType == "string":
+		d.Go, untyped = strconv.Quote(tag), true
+	case mapType == "int", mapType == "int64", mapType == "int32", mapType == "int16":
+		// int has no size in its name, and 0 means the native size
+		bits, _ := strconv.Atoi(mapType[len("int"):])
+		if _, err := strconv.ParseInt(tag, 10, bits); err != nil {
+			return nil, fmt.Errorf("%q isn't a valid %s", tag, mapType)
+		}
+
+		d.Go, untyped = tag, true
+	case mapType == "float32", mapType == "float64":
+		bits, _ := strconv.Atoi(mapType[len("float"):])
+		if !numberPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%q isn't a valid %s", tag, mapType)
+		}
+
+		v, err := strconv.ParseFloat(tag, bits)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("%q isn't a valid %s", tag, mapType)
+		}
+
+		d.Go, untyped = strconv.FormatFloat(v, 'g', -1, bits), true
+	case mapType == "bool":
+		if _, err := strconv.ParseBool(tag); err != nil {
+			return nil, fmt.Errorf("%q isn't a valid bool", tag)
+		}
+
+		d.Go, untyped = tag, true
+	case mapType == "decimal.Decimal":
+		if !numberPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%q isn't a valid decimal", tag)
+		}
+
+		d.Go = "decimal.RequireFromString(" + strconv.Quote(tag) + ")"
+	case mapType == "uuid.UUID":
+		if !uuidPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%q isn't a valid uuid", tag)
+		}
+
+		d.Go = "uuid.Must(uuid.FromString(" + strconv.Quote(tag) + "))"
+	case mapType == "time.Duration":
+		v, err := time.ParseDuration(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a valid duration", tag)
+		}
+
+		d.Go = fmt.Sprintf("time.Duration(%d)", v)
+	default:
+		return nil, fmt.Errorf("%s fields can't have a default of %q", mapType, tag)
+	}
+
+	if goType != mapType || (isPointer && untyped) {
+		d.Go = goType + "(" + d.Go + ")"
+	}
+
+	return &d, nil
+}
+
+// swaggerValue is the value of a literal default in a Swagger schema.
+func (d *Default) swaggerValue(mapping TypeMapping) interface{} {
+	if d.Kind != "literal" {
+		return nil
+	}
+
+	switch mapping.JS {
+	case "number":
+		v, _ := strconv.ParseFloat(d.Value, 64)
+		return v
+	case "boolean":
+		v, _ := strconv.ParseBool(d.Value)
+		return v
+	default:
+		return d.Value
+	}
+}
@@ -0,0 +1,142 @@
+package apigen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is a problem found in the definition of a model.
+type Diagnostic struct {
+	Pos     token.Position
+	Model   string
+	Field   string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	var s string
+	if d.Pos.IsValid() {
+		s = d.Pos.String() + ": "
+	}
+
+	switch {
+	case d.Model != "" && d.Field != "":
+		s += d.Model + "." + d.Field + ": "
+	case d.Model != "":
+		s += d.Model + ": "
+	}
+
+	return s + d.Message
+}
+
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		File    string `json:"file,omitempty"`
+		Line    int    `json:"line,omitempty"`
+		Column  int    `json:"column,omitempty"`
+		Model   string `json:"model,omitempty"`
+		Field   string `json:"field,omitempty"`
+		Message string `json:"message"`
+	}{d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Model, d.Field, d.Message})
+}
+
+// DiagnosticList collects diagnostics, so that all of the problems with a set
+// of models can be reported at once.
+type DiagnosticList []Diagnostic
+
+func (l DiagnosticList) Error() string {
+	a := make([]string, len(l))
+	for i, d := range l {
+		a[i] = d.String()
+	}
+
+	return strings.Join(a, "\n")
+}
+
+func (l DiagnosticList) sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		a, b := l[i].Pos, l[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// Print writes the diagnostics to wr, either as text (one per line) or as a
+// JSON array if format is "json".
+func (l DiagnosticList) Print(wr io.Writer, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(wr)
+		enc.SetIndent("", "  ")
+		if l == nil {
+			l = DiagnosticList{}
+		}
+		return enc.Encode(l)
+	default:
+		for _, d := range l {
+			if _, err := fmt.Fprintln(wr, d.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// modelSource is the source file a model was declared in, which is used to
+// attach positions to diagnostics.
+type modelSource struct {
+	fset *token.FileSet
+	file *ast.File
+}
+
+func (s *modelSource) position(pos token.Pos) token.Position {
+	if s == nil || s.fset == nil {
+		return token.Position{}
+	}
+
+	return s.fset.Position(pos)
+}
+
+// fieldPosition returns the position of a struct field, or of its tag if tag
+// is true and the field has one.
+func (s *modelSource) fieldPosition(f *types.Var, tag bool) token.Position {
+	if s == nil || s.file == nil || !tag {
+		return s.position(f.Pos())
+	}
+
+	pos := f.Pos()
+
+	ast.Inspect(s.file, func(n ast.Node) bool {
+		fl, ok := n.(*ast.Field)
+		if !ok || fl.Tag == nil {
+			return true
+		}
+
+		if len(fl.Names) == 0 && fl.Type.Pos() <= f.Pos() && f.Pos() < fl.Type.End() {
+			pos = fl.Tag.Pos()
+			return false
+		}
+
+		for _, name := range fl.Names {
+			if name.Pos() == f.Pos() {
+				pos = fl.Tag.Pos()
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return s.position(pos)
+}
@@ -0,0 +1,62 @@
+package apigen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiagnostics checks that the problems with every model are reported
+// together, in order, with the positions of the fields or tags at fault.
+func TestDiagnostics(t *testing.T) {
+	dir, cfg := freshTree(t)
+
+	broken := filepath.Join(dir, "models", "broken.go")
+
+	mustNoError(t, ioutil.WriteFile(broken, []byte(`package models
+
+// @apigen
+type Gizmo struct {
+	ID     int
+	Colour string `+"`enum:\"red|blue\" validate:\"unique\"`"+`
+}
+
+// @apigen
+type Gadget struct {
+	ID   int
+	Size complex128
+	Cost int64
+}
+`), 0644))
+
+	_, err := Generate(context.Background(), *cfg)
+
+	var l DiagnosticList
+	if !errors.As(err, &l) {
+		t.Fatalf("expected a DiagnosticList, got %v", err)
+	}
+
+	var text bytes.Buffer
+	mustNoError(t, l.Print(&text, "text"))
+
+	assert.Equal(t, broken+`:6:16: Gizmo.Colour: bad validate tag: unknown rule "unique"
+`+broken+`:12:2: Gadget.Size: complex128 isn't a type apigen knows about; declare it in the config's types
+`+broken+":13:2: Gadget.Cost: int64 is a string in JS, as not all of its values fit in a JS number, so the field has to be a string in JSON too; add the json string option, e.g. `json:\",string\"`, which makes API clients send and receive it as a string, or use a smaller type such as int32 if its values fit\n", text.String())
+
+	var js bytes.Buffer
+	mustNoError(t, l[:1].Print(&js, "json"))
+
+	assert.JSONEq(t, `[{"file": `+strconv.Quote(broken)+`, "line": 6, "column": 16, "model": "Gizmo", "field": "Colour", "message": "bad validate tag: unknown rule \"unique\""}]`, js.String())
+
+	// an empty list is still a list in JSON, so that it can be read
+	// without special cases
+	js.Reset()
+	mustNoError(t, DiagnosticList(nil).Print(&js, "json"))
+	assert.Equal(t, "[]\n", js.String())
+}
@@ -0,0 +1,116 @@
+package apigen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+func match(pattern, value string) bool {
+	pattern = strings.ReplaceAll(pattern, ".", "\\.")
+	pattern = strings.ReplaceAll(pattern, "*", ".*")
+	pattern = strings.ReplaceAll(pattern, "?", ".")
+	match, _ := regexp.MatchString("^"+pattern+"$", value)
+	return match
+}
+
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, e := range patterns {
+		if match(e, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriterFilter selects writers by model name and writer name, e.g.
+// "Widget,Order*:api/*". Aggregated writers have the model name "_".
+type WriterFilter struct {
+	modelFilters  []string
+	writerFilters []string
+}
+
+func (f *WriterFilter) match(modelName, writerName string) bool {
+	return matchAny(f.modelFilters, modelName) && matchAny(f.writerFilters, writerName)
+}
+
+func (f *WriterFilter) String() string {
+	if len(f.writerFilters) == 0 {
+		return strings.Join(f.modelFilters, ",")
+	}
+
+	return strings.Join(f.modelFilters, ",") + ":" + strings.Join(f.writerFilters, ",")
+}
+
+func (f *WriterFilter) UnmarshalText(d []byte) error {
+	sets := strings.Split(string(d), ":")
+	if len(sets) > 2 {
+		return fmt.Errorf("too many sets of filters")
+	}
+
+	if len(sets) > 0 && sets[0] != "" {
+		f.modelFilters = strings.Split(sets[0], ",")
+	}
+	if len(sets) > 1 && sets[1] != "" {
+		f.writerFilters = strings.Split(sets[1], ",")
+	}
+
+	return nil
+}
+
+func (f *WriterFilter) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// WriterFilterList matches a writer if any of its filters do, or if it's
+// empty. It implements flag.Value, so it can be given on the command line.
+type WriterFilterList []WriterFilter
+
+func (l WriterFilterList) match(modelName, writerName string) bool {
+	if len(l) == 0 {
+		return true
+	}
+
+	for _, e := range l {
+		if e.match(modelName, writerName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchModel is like match, but only considers the model part of each filter.
+func (l WriterFilterList) matchModel(modelName string) bool {
+	if len(l) == 0 {
+		return true
+	}
+
+	for _, e := range l {
+		if matchAny(e.modelFilters, modelName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *WriterFilterList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *WriterFilterList) Set(s string) error {
+	var f WriterFilter
+	if err := f.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+
+	*l = append(*l, f)
+
+	return nil
+}
@@ -0,0 +1,1853 @@
+package apigen
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/danverbraganza/varcaser/varcaser"
+	"github.com/grsmv/inflect"
+)
+
+type generator interface {
+	Name() string
+}
+
+type generatorForModel interface {
+	generator
+	Model(model *Model) []writer
+}
+
+type generatorForModels interface {
+	generator
+	Models(models []*Model) []writer
+}
+
+type writer interface {
+	Name() string
+	Language() string
+	File() string
+	Write(wr io.Writer) error
+}
+
+type writerForGo interface {
+	PackageName() string
+	Imports() []string
+}
+
+// writerWithHash is implemented by writers that can summarise their input,
+// which allows their output to be cached. An empty hash means the output
+// can't be cached.
+type writerWithHash interface {
+	Hash() string
+}
+
+type basicWriter struct {
+	name     string
+	language string
+	file     string
+	hash     string
+	write    func(wr io.Writer) error
+}
+
+func (w *basicWriter) Name() string             { return w.name }
+func (w *basicWriter) Language() string         { return w.language }
+func (w *basicWriter) File() string             { return w.file }
+func (w *basicWriter) Hash() string             { return w.hash }
+func (w *basicWriter) Write(wr io.Writer) error { return w.write(wr) }
+
+type basicWriterForGo struct {
+	basicWriter
+	packageName string
+	imports     []string
+}
+
+func (w *basicWriterForGo) PackageName() string { return w.packageName }
+func (w *basicWriterForGo) Imports() []string   { return w.imports }
+
+func (st *settings) templateWriter(tpl string, vars map[string]interface{}) func(wr io.Writer) error {
+	t := template.Must(template.New("").Funcs(st.funcs).Parse(tpl))
+
+	return func(wr io.Writer) error {
+		return t.Execute(wr, vars)
+	}
+}
+
+// templateHash summarises the input to templateWriter.
+func templateHash(tpl string, vars map[string]interface{}) string {
+	h := sha256.New()
+
+	if _, err := io.WriteString(h, tpl); err != nil {
+		return ""
+	}
+	if err := json.NewEncoder(h).Encode(vars); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var headerTemplate = template.Must(template.New("header").Parse(`package {{.PackageName}}
+
+import (
+{{range $s := .Imports}}
+	"{{$s}}"
+{{- end}}
+)
+
+`))
+
+var defaultUpperCaseOverrides = []string{
+	"ADBOR",
+	"API",
+	"AVC",
+	"BSI",
+	"CIDN",
+	"CMP",
+	"CPE",
+	"CVC",
+	"EOMSYS",
+	"FBS",
+	"FNN",
+	"FTTP",
+	"ID",
+	"IDEAL",
+	"IP",
+	"LOLO",
+	"MAC",
+	"MLO",
+	"MNP",
+	"NBN",
+	"NTD",
+	"OMFUL",
+	"PM",
+	"PMV",
+	"PONR",
+	"SIM",
+	"SIP",
+	"SMDM",
+	"SQv2",
+	"SQv3",
+	"SQ",
+	"TWI",
+	"UI",
+	"UNID",
+	"UNIV",
+	"UNMS",
+	"VISP",
+	"WAS",
+	"WBI",
+	"WLI",
+	"WME",
+}
+
+var lowerCaseOverrides = []string{
+	"a",
+	"an",
+	"and",
+	"as",
+	"at",
+	"but",
+	"by",
+	"for",
+	"if",
+	"in",
+	"nor",
+	"of",
+	"off",
+	"on",
+	"or",
+	"per",
+	"so",
+	"the",
+	"to",
+	"up",
+	"via",
+	"yet",
+}
+
+func decorateWordCaseWithCaseOverrides(fn varcaser.WordCase, a ...[]string) varcaser.WordCase {
+	return varcaser.WordCase(func(word string) string {
+		for _, l := range a {
+			for _, e := range l {
+				if strings.ToLower(word) == strings.ToLower(e) {
+					return e
+				}
+			}
+		}
+
+		return fn(word)
+	})
+}
+
+func decorateCaseConventionWithCaseOverrides(c varcaser.CaseConvention, upperCaseOverrides []string) varcaser.CaseConvention {
+	return varcaser.CaseConvention{
+		JoinStyle:      c.JoinStyle,
+		InitialCase:    decorateWordCaseWithCaseOverrides(c.InitialCase, upperCaseOverrides),
+		SubsequentCase: decorateWordCaseWithCaseOverrides(c.SubsequentCase, upperCaseOverrides),
+		Example:        c.Example,
+	}
+}
+
+func inSlice(a []string, s string) bool {
+	for _, e := range a {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (st *settings) splitWords(s string) []string {
+	// NOTE(danver): While I keep finding new edge cases, I'll want
+	// this to be easy-to-modify code rather than a regex.
+
+	var words []string
+
+	wasPreviousUpper := true
+	current := []rune{}
+
+	for _, c := range s {
+		if wasPreviousUpper && unicode.IsUpper(c) {
+			// If previous was uppercase, and this is
+			// uppercase, continue the word.
+
+			current = append(current, c)
+
+			if inSlice(st.upperCaseOverrides, string(current)) {
+				words = append(words, string(current))
+				current = []rune{}
+			}
+		} else if wasPreviousUpper && !unicode.IsUpper(c) {
+			// If the previous run was uppercase, but this
+			// is not, set previous, but add it.
+
+			// Edge case: the previous word was all uppercase.
+			if len(current) > 1 {
+				words = append(words, string(current[:len(current)-1]))
+				current = current[len(current)-1:]
+			}
+
+			current = append(current, c)
+			wasPreviousUpper = false
+		} else if !wasPreviousUpper && unicode.IsUpper(c) {
+			// If the previous rune was not uppercase, and
+			// this character is, put current into
+			// components first, then set wasPreviousUpper
+
+			words = append(words, string(current))
+			current = []rune{c}
+			wasPreviousUpper = true
+		} else if !wasPreviousUpper && !unicode.IsUpper(c) {
+			// If the previous rune was not uppercase, and
+			// this one is not, just add to this component.
+
+			current = append(current, c)
+		}
+	}
+
+	if len(current) != 0 {
+		words = append(words, string(current))
+	}
+
+	return words
+}
+
+func getFieldIndex(t *types.Struct, name string) (int, bool) {
+	for i := 0; i < t.NumFields(); i++ {
+		if f := t.Field(i); f.Name() == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func getField(t *types.Struct, field string) *types.Var {
+	if i, ok := getFieldIndex(t, field); ok {
+		return t.Field(i)
+	}
+
+	return nil
+}
+
+func getTagIndex(t *types.Struct, field int, tag string) string {
+	return reflect.StructTag(t.Tag(field)).Get(tag)
+}
+
+func getTag(t *types.Struct, field, tag string) string {
+	if i, ok := getFieldIndex(t, field); ok {
+		return getTagIndex(t, i, tag)
+	}
+
+	return ""
+}
+
+func parseTag(tag string) (string, map[string][][]string) {
+	bits := strings.Split(tag, ",")
+
+	m := make(map[string][][]string)
+
+	if len(bits) > 1 {
+		for _, e := range bits[1:] {
+			if a := strings.Split(e, ":"); len(a) == 1 {
+				m[a[0]] = append(m[a[0]], nil)
+			} else {
+				m[a[0]] = append(m[a[0]], a[1:])
+			}
+		}
+	}
+
+	return bits[0], m
+}
+
+// modelDirectives are the model-wide options given in the @apigen comment,
+// e.g. "@apigen table=service_orders plural=ServiceOrders noaudit sql=save".
+type modelDirectives struct {
+	table       string
+	plural      string
+	lowerPlural string
+	noAudit     bool
+	noSearch    bool
+	noGet       bool
+	noCreate    bool
+	noUpdate    bool
+	typedEnums  bool
+	sql         map[string]bool
+}
+
+var sqlDirectives = []string{"findOne", "findOneByID", "findMultiple", "create", "save"}
+
+// parseModelDirectives parses the text of an @apigen comment. It returns nil
+// if the comment isn't an @apigen comment at all.
+func parseModelDirectives(text string) (*modelDirectives, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 || words[0] != "@apigen" {
+		return nil, nil
+	}
+
+	d := modelDirectives{sql: make(map[string]bool)}
+
+	for _, e := range words[1:] {
+		k, v, hasValue := strings.Cut(e, "=")
+
+		switch k {
+		case "table", "plural", "lowerPlural", "sql":
+			if !hasValue || v == "" {
+				return nil, fmt.Errorf("@apigen option %q needs a value, e.g. %s=x", k, k)
+			}
+		case "noaudit", "nosearch", "noget", "nocreate", "noupdate", "typedenums":
+			if hasValue {
+				return nil, fmt.Errorf("@apigen option %q doesn't take a value", k)
+			}
+		}
+
+		switch k {
+		case "table":
+			d.table = v
+		case "plural":
+			d.plural = v
+		case "lowerPlural":
+			d.lowerPlural = v
+		case "noaudit":
+			d.noAudit = true
+		case "nosearch":
+			d.noSearch = true
+		case "noget":
+			d.noGet = true
+		case "nocreate":
+			d.noCreate = true
+		case "noupdate":
+			d.noUpdate = true
+		case "typedenums":
+			d.typedEnums = true
+		case "sql":
+			for _, op := range strings.Split(v, ",") {
+				if !inSlice(sqlDirectives, op) {
+					return nil, fmt.Errorf("unknown @apigen sql option %q; options are %s", op, strings.Join(sqlDirectives, ", "))
+				}
+
+				d.sql[op] = true
+			}
+		default:
+			return nil, fmt.Errorf("unknown @apigen option %q", k)
+		}
+	}
+
+	return &d, nil
+}
+
+func getAndParseTag(t *types.Struct, field, tag string) (string, map[string][][]string) {
+	return parseTag(getTag(t, field, tag))
+}
+
+func getAndParseTagIndex(t *types.Struct, field int, tag string) (string, map[string][][]string) {
+	return parseTag(getTagIndex(t, field, tag))
+}
+
+func (st *settings) pluralFor(s string) string {
+	a := st.splitWords(s)
+
+	a[len(a)-1] = inflect.Pluralize(a[len(a)-1])
+
+	for i, e := range a {
+		a[i] = strings.ToLower(e)
+	}
+
+	return varcaser.LowerSnakeCase.Join(a)
+}
+
+// isByteSlice reports whether t is []byte.
+func isByteSlice(t *types.Slice) bool {
+	b, ok := t.Elem().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
+
+// hasMethod reports whether t or a pointer to it has the named method.
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name) != nil
+}
+
+var defaultIgnoreCreate = map[string]bool{
+	"id":        true,
+	"version":   true,
+	"createdAt": true,
+	"updatedAt": true,
+	"creatorId": true,
+	"updaterId": true,
+}
+
+var defaultIgnoreUpdate = map[string]bool{
+	"id":        true,
+	"version":   true,
+	"createdAt": true,
+	"updatedAt": true,
+	"creatorId": true,
+	"updaterId": true,
+}
+
+func formatGo(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var arr []string
+		for _, k := range keys {
+			arr = append(arr, formatGo(k)+": "+formatGo(v[k])+",\n")
+		}
+
+		return fmt.Sprintf("map[string]interface{}{\n%s}", strings.Join(arr, ""))
+	default:
+		vv := reflect.ValueOf(v)
+		t := vv.Type()
+
+		switch vv.Kind() {
+		case reflect.Slice:
+			tt := t.Elem()
+
+			var arr []string
+			for i := 0; i < vv.Len(); i++ {
+				arr = append(arr, formatGo(vv.Index(i).Interface())+",\n")
+			}
+
+			return fmt.Sprintf("[]%s{\n%s}", tt.String(), strings.Join(arr, ""))
+		case reflect.Struct:
+			var arr []string
+			for i := 0; i < t.NumField(); i++ {
+				tf := t.Field(i)
+				vf := vv.Field(i)
+
+				if !vf.CanInterface() {
+					continue
+				}
+
+				if reflect.DeepEqual(vf.Interface(), reflect.Zero(vf.Type()).Interface()) {
+					continue
+				}
+
+				arr = append(arr, tf.Name+": "+formatGo(vf.Interface())+",\n")
+			}
+
+			return fmt.Sprintf("%s{\n%s}", t.String(), strings.Join(arr, ""))
+		default:
+			return fmt.Sprintf("%#v", v)
+		}
+	}
+}
+
+// enumKey converts v, a value of an enum field (or of a member of one) of type
+// t, to the string that the enum maps are keyed by.
+func enumKey(v, t string) string {
+	if strings.TrimPrefix(t, "[]") == "string" {
+		return v
+	}
+
+	return "string(" + v + ")"
+}
+
+var tplFunc = template.FuncMap{
+	"Hash": func(a ...string) string {
+		h := sha256.New()
+		for _, e := range a {
+			_, _ = h.Write([]byte(e))
+		}
+		v := base64.StdEncoding.EncodeToString(h.Sum(nil))
+		return v[0:6]
+	},
+	"Dump": formatGo,
+	"Default": func(defaultValue, input string) string {
+		if input == "" {
+			return defaultValue
+		}
+
+		return input
+	},
+	"PackageName": func(suffix, modelName string) string {
+		return strings.ToLower(modelName) + suffix
+	},
+	"LC": func(s string) string {
+		return strings.ToLower(s)
+	},
+	"UnPtr": func(s string) string {
+		return strings.TrimPrefix(s, "*")
+	},
+	"Join": func(s1, s2 string) string {
+		return s1 + s2
+	},
+	"EnumKey": enumKey,
+	// EnumInvalid is an expression that's true if v, a value of enum field f
+	// (or of a member of one), isn't one of its values. pkg is the name of
+	// the enum package.
+	"EnumInvalid": func(pkg, v string, f Field) string {
+		if f.EnumType != "" {
+			return "!" + v + ".IsValid()"
+		}
+
+		return "!" + pkg + ".Valid" + f.GoName + "[" + enumKey(v, f.GoType) + "]"
+	},
+	"EqualStrings": func(s1, s2 string) bool {
+		return s1 == s2
+	},
+	"FormatTemplate": func(t string) string {
+		switch t {
+		case "uuid.UUID":
+			return "%q"
+		case "int":
+			return "%d"
+		default:
+			return "%#v"
+		}
+	},
+	// CSVValue returns a Go expression formatting v, the value of field f,
+	// for CSV exports.
+	"CSVValue": func(f Field, v string) string {
+		if f.CSV != "" {
+			return expand(f.CSV, v, "", false)
+		}
+
+		return fmt.Sprintf("fmt.Sprintf(\"%%v\", %s)", v)
+	},
+	// Expand substitutes v for $1 in expr, e.g. the expressions of a
+	// ValidationCheck.
+	"Expand": func(expr, v string) string {
+		return expand(expr, v, "", false)
+	},
+	"JSString": jsString,
+	"EmailPattern": func() string {
+		return emailPattern
+	},
+}
+
+type Model struct {
+	Singular         string `json:"singular"`
+	Plural           string `json:"plural"`
+	LowerPlural      string `json:"lowerPlural"`
+	LowerSnakePlural string `json:"lowerSnakePlural"`
+
+	IDField      *Field    `json:"idField"`
+	VersionField *Field    `json:"versionField"`
+	Fields       FieldList `json:"fields"`
+
+	SpecialOrders  []SpecialOrder `json:"specialOrders"`
+	SpecialFilters []Filter       `json:"specialFilters"`
+
+	Processes []string `json:"processes"`
+
+	HasID        bool `json:"hasId"`
+	HasVersion   bool `json:"hasVersion"`
+	HasCreatedAt bool `json:"hasCreatedAt"`
+	HasUpdatedAt bool `json:"hasUpdatedAt"`
+	HasCreatorID bool `json:"hasCreatorId"`
+	HasUpdaterID bool `json:"hasUpdaterId"`
+
+	HasAudit      bool `json:"hasAudit"`
+	HasUserFilter bool `json:"hasUserFilter"`
+
+	HasAPISearch bool `json:"hasApiSearch"`
+	HasAPIGet    bool `json:"hasApiGet"`
+	HasAPICreate bool `json:"hasApiCreate"`
+	HasAPIUpdate bool `json:"hasApiUpdate"`
+
+	SQLTableName       string `json:"sqlTableName"`
+	HasSQLFindOne      bool   `json:"hasSqlFindOne"`
+	HasSQLFindOneByID  bool   `json:"hasSqlFindOneById"`
+	HasSQLFindMultiple bool   `json:"hasSqlFindMultiple"`
+	HasSQLCreate       bool   `json:"hasSqlCreate"`
+	HasSQLSave         bool   `json:"hasSqlSave"`
+
+	// warnings are problems with the model that don't stop code from being
+	// generated, e.g. deprecated options.
+	warnings DiagnosticList
+}
+
+type Field struct {
+	IsNull bool `json:"isNull"`
+	Array  bool `json:"array"`
+
+	GoName     string `json:"goName"`
+	GoType     string `json:"goType"`
+	ScanType   string `json:"scanType"`
+	FormatType string `json:"formatType"`
+	// CompareType is the type the field is compared as when checking for
+	// changes. It's usually the same as GoType, but named types are
+	// compared as their underlying type, and "any" means there's nothing
+	// better than a deep comparison.
+	CompareType string `json:"compareType"`
+	// CSV is a Go expression formatting $1 for CSV exports, if it's not
+	// done with %v.
+	CSV string `json:"csv,omitempty"`
+	// JSON is set for fields that are stored as jsonb, which are converted
+	// to and from json when they're read and written.
+	JSON bool `json:"json,omitempty"`
+	// GoImport is the import path of the package GoType is declared in, if
+	// it's from a package other than the models package.
+	GoImport string `json:"goImport,omitempty"`
+
+	SQLName string `json:"sqlName"`
+	SQLType string `json:"sqlType"`
+
+	APIName     string       `json:"apiName"`
+	APIRefs     []APIRef     `json:"apiRefs"`
+	JSType      string       `json:"jsType"`
+	FlowType    string       `json:"flowType"`
+	SwaggerType *SwaggerType `json:"swaggerType"`
+
+	Filters []Filter `json:"filters"`
+
+	IgnoreCreate   bool     `json:"ignoreCreate"`
+	IgnoreUpdate   bool     `json:"ignoreUpdate"`
+	OmitEmpty      bool     `json:"omitEmpty"`
+	Enum           EnumList `json:"enum"`
+	Sequence       string   `json:"sequence"`
+	SequencePrefix string   `json:"sequencePrefix"`
+	// Validate is set if the field has a validate tag.
+	Validate *Validation `json:"validate,omitempty"`
+	// Default is set if the field has a default tag.
+	Default *Default `json:"default,omitempty"`
+	// TypedEnum is set if the enum package has a type for the field's
+	// values, and EnumType is that type, if the field is declared with it.
+	TypedEnum bool   `json:"typedEnum,omitempty"`
+	EnumType  string `json:"enumType,omitempty"`
+}
+
+func (f Field) HasEnumValue(value string) bool {
+	return f.Enum.HasValue(value)
+}
+
+func (f Field) HasEnumValues(values []string) bool {
+	return f.Enum.HasValues(values)
+}
+
+type FieldList []Field
+
+func (l FieldList) GetByName(name string) *Field {
+	for _, f := range l {
+		if f.GoName == name || f.SQLName == name || f.APIName == name {
+			return &f
+		}
+	}
+
+	return nil
+}
+
+func (l FieldList) GetByNameAndType(name, typ string) *Field {
+	if f := l.GetByName(name); f != nil {
+		if f.GoType == typ || f.SQLType == typ || f.JSType == typ || f.FlowType == typ {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func (l FieldList) HasFieldWithNameAndType(name, typ string) bool {
+	return l.GetByNameAndType(name, typ) != nil
+}
+
+func (l FieldList) HasFieldsWithNamesAndTypes(pairs [][2]string) bool {
+	for _, pair := range pairs {
+		if !l.HasFieldWithNameAndType(pair[0], pair[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasJSON reports whether any of the fields are stored as jsonb.
+func (l FieldList) HasJSON() bool {
+	for _, f := range l {
+		if f.JSON {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasValidation reports whether any of the fields have a validate tag.
+func (l FieldList) HasValidation() bool {
+	for _, f := range l {
+		if f.Validate != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasEmailValidation reports whether any of the fields have to be email
+// addresses.
+func (l FieldList) HasEmailValidation() bool {
+	for _, f := range l {
+		if f.Validate != nil && f.Validate.Email {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasValidationPatterns reports whether any of the fields are checked with
+// regular expressions, i.e. have to match a pattern or be email addresses.
+func (l FieldList) HasValidationPatterns() bool {
+	for _, f := range l {
+		if f.Validate != nil && (f.Validate.Pattern != "" || f.Validate.Email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasTypedEnums reports whether any of the fields have a type of their own in
+// the enum package.
+func (l FieldList) HasTypedEnums() bool {
+	for _, f := range l {
+		if f.TypedEnum {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withFieldImports adds the packages that the types of the fields in l are
+// declared in to imports, unless they're there already.
+func withFieldImports(imports []string, l FieldList) []string {
+	for _, f := range l {
+		if f.GoImport != "" && !inSlice(imports, f.GoImport) {
+			imports = append(imports, f.GoImport)
+		}
+	}
+
+	return imports
+}
+
+type APIRef struct {
+	ModelName string `json:"modelName"`
+	FieldName string `json:"fieldName"`
+}
+
+type Enum struct {
+	Value  string `json:"value"`
+	Label  string `json:"label"`
+	GoName string `json:"goName"`
+}
+
+type EnumList []Enum
+
+func (l EnumList) First() Enum {
+	return l[0]
+}
+
+func (l EnumList) Values() []string {
+	a := make([]string, len(l))
+	for i, e := range l {
+		a[i] = e.Value
+	}
+
+	return a
+}
+
+func (l EnumList) GetByValue(value string) *Enum {
+	for _, e := range l {
+		if e.Value == value {
+			return &e
+		}
+	}
+
+	return nil
+}
+
+func (l EnumList) HasValue(value string) bool {
+	return l.GetByValue(value) != nil
+}
+
+func (l EnumList) HasValues(values []string) bool {
+	for _, e := range values {
+		if !l.HasValue(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type SpecialOrder struct {
+	GoName  string `json:"goName"`
+	APIName string `json:"apiName"`
+}
+
+type Filter struct {
+	Operator    string       `json:"operator"`
+	Name        string       `json:"name"`
+	GoName      string       `json:"goName"`
+	GoType      string       `json:"goType"`
+	JSType      string       `json:"jsType"`
+	FlowType    string       `json:"flowType"`
+	SwaggerType *SwaggerType `json:"swaggerType"`
+	// JSONString is set if the filter's value is encoded as a string in
+	// JSON, like the field it's for.
+	JSONString bool `json:"jsonString,omitempty"`
+}
+
+// structField is a field of a model's struct, which might have been promoted
+// from a struct embedded in it.
+type structField struct {
+	t     *types.Struct
+	i     int
+	depth int
+}
+
+// flattenFields lists the fields of t in order, replacing each exported
+// embedded struct with its own fields, recursively. As with promotion in Go,
+// a field hides any deeper field of the same name, and fields of the same
+// name at the same depth are an error.
+func flattenFields(t *types.Struct, report func(f *types.Var, tag bool, format string, args ...interface{})) []structField {
+	var fields []structField
+
+	var walk func(t *types.Struct, depth int)
+	walk = func(t *types.Struct, depth int) {
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+
+			if !f.Embedded() || !f.Exported() {
+				fields = append(fields, structField{t: t, i: i, depth: depth})
+				continue
+			}
+
+			if apiName, _ := getAndParseTagIndex(t, i, "api"); apiName == "-" {
+				continue
+			}
+
+			if _, ok := f.Type().(*types.Pointer); ok {
+				report(f, false, "embedded struct pointers aren't supported; embed %s by value", f.Name())
+				continue
+			}
+
+			et, ok := f.Type().Underlying().(*types.Struct)
+			if !ok {
+				fields = append(fields, structField{t: t, i: i, depth: depth})
+				continue
+			}
+
+			if jsonName, _ := getAndParseTagIndex(t, i, "json"); jsonName != "" && jsonName != "-" {
+				report(f, true, "embedded structs can't be given a json name, as their fields wouldn't be promoted")
+				continue
+			}
+
+			walk(et, depth+1)
+		}
+	}
+
+	walk(t, 0)
+
+	shallowest := make(map[string]int)
+	for _, sf := range fields {
+		name := sf.t.Field(sf.i).Name()
+		if d, ok := shallowest[name]; !ok || sf.depth < d {
+			shallowest[name] = sf.depth
+		}
+	}
+
+	var flattened []structField
+	seen := make(map[string]bool)
+	for _, sf := range fields {
+		f := sf.t.Field(sf.i)
+
+		if sf.depth != shallowest[f.Name()] {
+			continue
+		}
+
+		if seen[f.Name()] {
+			report(f, false, "field is ambiguous; %s is also promoted from another embedded struct", f.Name())
+			continue
+		}
+		seen[f.Name()] = true
+
+		flattened = append(flattened, sf)
+	}
+
+	return flattened
+}
+
+func (st *settings) makeModel(src *modelSource, directives *modelDirectives, typeName string, namedType *types.Named, structType *types.Struct) (*Model, error) {
+	var diagnostics, warnings DiagnosticList
+
+	report := func(f *types.Var, tag bool, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Pos:     src.fieldPosition(f, tag),
+			Model:   typeName,
+			Field:   f.Name(),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// model-wide options used to be given in field tags, which still works,
+	// but they belong in the @apigen comment now
+	deprecated := func(f *types.Var, tag, option, directive string) {
+		warnings = append(warnings, Diagnostic{
+			Pos:     src.fieldPosition(f, true),
+			Model:   typeName,
+			Field:   f.Name(),
+			Message: fmt.Sprintf("%s:\",%s\" in a field tag is deprecated; use \"@apigen %s\" instead", tag, option, directive),
+		})
+	}
+
+	if directives == nil {
+		directives = &modelDirectives{}
+	}
+
+	typed := st.typedEnums || directives.typedEnums
+
+	words := st.splitWords(typeName)
+
+	words[len(words)-1] = inflect.Pluralize(words[len(words)-1])
+
+	for i, e := range words {
+		if i == 0 {
+			words[i] = st.upperCamelLowerCamelCaps.To.InitialCase(e)
+		} else {
+			words[i] = st.upperCamelLowerCamelCaps.To.SubsequentCase(e)
+		}
+	}
+
+	var (
+		lowerPlural        = st.upperCamelLowerCamelCaps.To.Join(words)
+		lowerSnakePlural   = st.pluralFor(typeName)
+		sqlTableName       = st.pluralFor(typeName)
+		fields             FieldList
+		specialOrders      []SpecialOrder
+		specialFilters     []Filter
+		hasID              = false
+		hasVersion         = false
+		hasCreatedAt       = false
+		hasUpdatedAt       = false
+		hasCreatorID       = false
+		hasUpdaterID       = false
+		hasUserFilter      = false
+		hasAPINoAudit      = false
+		hasAPINoSearch     = false
+		hasAPINoGet        = false
+		hasAPINoCreate     = false
+		hasAPINoUpdate     = false
+		hasSQLFindOne      = false
+		hasSQLFindOneByID  = false
+		hasSQLFindMultiple = false
+		hasSQLCreate       = false
+		hasSQLSave         = false
+	)
+
+fields:
+	for _, sf := range flattenFields(structType, report) {
+		f := sf.t.Field(sf.i)
+
+		jsonName, _ := getAndParseTagIndex(sf.t, sf.i, "json")
+		if jsonName == "-" {
+			jsonName = ""
+		}
+
+		apiName, apiTagOptions := getAndParseTagIndex(sf.t, sf.i, "api")
+		if apiName == "-" {
+			continue
+		} else if apiName == "" {
+			if jsonName != "" {
+				apiName = jsonName
+			} else {
+				apiName = st.upperCamelLowerCamel.String(f.Name())
+			}
+		}
+
+		if a, ok := apiTagOptions["lowerPlural"]; ok && len(a) > 0 && len(a[0]) > 0 {
+			deprecated(f, "api", "lowerPlural:"+a[0][0], "lowerPlural="+a[0][0])
+			lowerPlural = a[0][0]
+		}
+
+		if apiName == "id" {
+			hasID = true
+		}
+		if apiName == "version" {
+			hasVersion = true
+		}
+		if apiName == "createdAt" {
+			hasCreatedAt = true
+		}
+		if apiName == "creatorId" {
+			hasCreatorID = true
+		}
+		if apiName == "updatedAt" {
+			hasUpdatedAt = true
+		}
+		if apiName == "updaterId" {
+			hasUpdaterID = true
+		}
+
+		if _, ok := apiTagOptions["noaudit"]; ok {
+			deprecated(f, "api", "noaudit", "noaudit")
+			hasAPINoAudit = true
+		}
+		if _, ok := apiTagOptions["nosearch"]; ok {
+			deprecated(f, "api", "nosearch", "nosearch")
+			hasAPINoSearch = true
+		}
+		if _, ok := apiTagOptions["noget"]; ok {
+			deprecated(f, "api", "noget", "noget")
+			hasAPINoGet = true
+		}
+		if _, ok := apiTagOptions["nocreate"]; ok {
+			deprecated(f, "api", "nocreate", "nocreate")
+			hasAPINoCreate = true
+		}
+		if _, ok := apiTagOptions["noupdate"]; ok {
+			deprecated(f, "api", "noupdate", "noupdate")
+			hasAPINoUpdate = true
+		}
+
+		sqlName, sqlTagOptions := getAndParseTagIndex(sf.t, sf.i, "sql")
+		if sqlName == "" {
+			sqlName = st.upperCamelLowerSnake.String(f.Name())
+		}
+
+		if a, ok := sqlTagOptions["table"]; ok && len(a) > 0 {
+			deprecated(f, "sql", "table:"+a[0][0], "table="+a[0][0])
+			sqlTableName = a[0][0]
+		}
+
+		if _, ok := sqlTagOptions["findOne"]; ok {
+			deprecated(f, "sql", "findOne", "sql=findOne")
+			hasSQLFindOne = true
+		}
+		if _, ok := sqlTagOptions["findOneByID"]; ok {
+			deprecated(f, "sql", "findOneByID", "sql=findOneByID")
+			hasSQLFindOneByID = true
+		}
+		if _, ok := sqlTagOptions["findMultiple"]; ok {
+			deprecated(f, "sql", "findMultiple", "sql=findMultiple")
+			hasSQLFindMultiple = true
+		}
+		if _, ok := sqlTagOptions["create"]; ok {
+			deprecated(f, "sql", "create", "sql=create")
+			hasSQLCreate = true
+		}
+		if _, ok := sqlTagOptions["save"]; ok {
+			deprecated(f, "sql", "save", "sql=save")
+			hasSQLSave = true
+		}
+
+		var enums EnumList
+		if s := getTagIndex(sf.t, sf.i, "enum"); s != "" {
+			a := strings.Split(s[1:], string(s[0]))
+
+			enums = make(EnumList, len(a))
+
+			for i, s := range a {
+				b := strings.SplitN(s, ":", 3)
+				if len(b) == 1 {
+					b = append(b, st.lowerKebabTitleCase.String(b[0]))
+				}
+				if len(b) == 2 {
+					v := b[0]
+					if v == "" {
+						v = "empty"
+					}
+
+					b = append(b, st.lowerKebabUpperCamelCaps.String(v))
+				}
+
+				label, err := url.QueryUnescape(b[1])
+				if err != nil {
+					report(f, true, "bad percent-encoding in enum: %v", err)
+					continue fields
+				}
+				b[1] = label
+
+				enums[i] = Enum{b[0], b[1], b[2]}
+			}
+		}
+
+		var validation *Validation
+		if s := getTagIndex(sf.t, sf.i, "validate"); s != "" {
+			v, err := parseValidation(s)
+			if err != nil {
+				report(f, true, "bad validate tag: %v", err)
+				continue fields
+			}
+
+			validation = v
+		}
+
+		defaultValue := getTagIndex(sf.t, sf.i, "default")
+
+		ft := f.Type()
+
+		// byte slices are a single binary value, rather than a slice
+		isSlice := false
+		if p, ok := ft.(*types.Slice); ok && !isByteSlice(p) {
+			isSlice = true
+			ft = p.Elem()
+		}
+
+		isPointer := false
+		if p, ok := ft.(*types.Pointer); ok {
+			isPointer = true
+			ft = p.Elem()
+		}
+
+		_, omitEmpty := apiTagOptions["omitempty"]
+
+		if omitEmpty && len(enums) > 0 {
+			found := false
+
+			for _, e := range enums {
+				if e.Value == "" {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				report(f, true, "can't specify omitEmpty with enum unless one is an empty string")
+				continue fields
+			}
+		}
+
+		var sequence, sequencePrefix string
+		for _, opt := range apiTagOptions["sequence"] {
+			switch len(opt) {
+			case 1:
+				sequence = opt[0]
+				sequencePrefix = ""
+			case 2:
+				sequence = opt[0]
+				sequencePrefix = opt[1]
+			default:
+				report(f, true, "sequence option needs exactly one or two parameters")
+				continue fields
+			}
+		}
+
+		gf := Field{
+			GoName:         f.Name(),
+			APIName:        apiName,
+			SQLName:        sqlName,
+			IgnoreCreate:   st.ignoreCreate[apiName],
+			IgnoreUpdate:   st.ignoreUpdate[apiName],
+			OmitEmpty:      omitEmpty,
+			Enum:           enums,
+			Sequence:       sequence,
+			SequencePrefix: sequencePrefix,
+		}
+
+		var goType, mapType string
+		var mapping TypeMapping
+		var declared, jsonb bool
+
+		if st.types.isJSONB(f.Type()) {
+			// structs, and slices and maps of them, are stored as json, and
+			// described in full to the client
+			js, flow, swagger, err := st.jsonbTypes(f.Type())
+			if err != nil {
+				report(f, false, "%v", err)
+				continue fields
+			}
+
+			jsonb = true
+			goType = types.TypeString(f.Type(), func(p *types.Package) string {
+				if p == namedType.Obj().Pkg() {
+					return ""
+				}
+
+				gf.GoImport = p.Path()
+
+				return p.Name()
+			})
+			mapType = goType
+			mapping = TypeMapping{JS: js, Flow: flow, SQL: "jsonb", Swagger: swagger, Format: "%v", CSV: "jsonColumn" + typeName + "{$1}.String()"}
+
+			// they're a single value as far as the database is concerned,
+			// which is null if it encodes as null in json. pointers are
+			// already nullable in the client types.
+			switch f.Type().(type) {
+			case *types.Slice, *types.Map:
+				mapping.JS, mapping.Flow = "?"+mapping.JS, "?"+mapping.Flow
+				mapping.Swagger.Nullable = true
+				gf.IsNull = true
+				mapping.Filters = []string{"is_null", "is_not_null"}
+				mapping.Zero = "len($1) == 0"
+			case *types.Pointer:
+				gf.IsNull = true
+				mapping.Filters = []string{"is_null", "is_not_null"}
+				mapping.Zero = "$1 == nil"
+			}
+			isSlice, isPointer = false, false
+		} else {
+			switch ft := ft.(type) {
+			case *types.Basic:
+				goType = ft.String()
+			case *types.Named:
+				goType = ft.Obj().Pkg().Name() + "." + ft.Obj().Name()
+			case *types.Alias:
+				// e.g. json.RawMessage, which is an alias when built with jsonv2
+				goType = ft.Obj().Pkg().Name() + "." + ft.Obj().Name()
+			case *types.Slice:
+				goType = "[]byte"
+			default:
+				report(f, false, "unrecognised field type %s", ft.String())
+				continue fields
+			}
+
+			// named types with a basic underlying type, like "type OrderStatus
+			// string", are represented the same way as that type, unless they're
+			// one of the types that apigen knows about already. declaring them in
+			// the config overrides parts of that.
+			mapType = goType
+			if _, builtin := builtinTypes[goType]; !builtin {
+				switch u := ft.Underlying().(type) {
+				case *types.Basic:
+					mapType = u.String()
+				case *types.Slice:
+					if isByteSlice(u) {
+						mapType = "[]byte"
+					}
+				}
+			}
+
+			m, known := st.types[mapType]
+			mapping = m
+			if mapType != goType {
+				if m, ok := st.types[goType]; ok {
+					mapping, known = mapping.override(m), true
+				}
+			}
+
+			if !known {
+				report(f, false, "%s isn't a type apigen knows about; declare it in the config's types", goType)
+				continue fields
+			}
+			if missing := mapping.missing(); len(missing) > 0 {
+				report(f, false, "no %s declared for %s in the config's types", strings.Join(missing, ", "), goType)
+				continue fields
+			}
+
+			_, declared = st.types[goType]
+			if _, builtin := builtinTypes[goType]; declared && !builtin {
+				// anything that isn't stored as a basic type has to be able to
+				// convert itself for the database and the API
+				if _, ok := ft.Underlying().(*types.Basic); !ok {
+					if !hasMethod(ft, "Scan") || !hasMethod(ft, "Value") {
+						report(f, false, "%s is declared in the config's types, but doesn't implement sql.Scanner and driver.Valuer", goType)
+						continue fields
+					}
+					if !hasMethod(ft, "MarshalJSON") && !hasMethod(ft, "MarshalText") {
+						report(f, false, "%s is declared in the config's types, but doesn't implement json.Marshaler or encoding.TextMarshaler", goType)
+						continue fields
+					}
+				}
+			}
+
+			if mapping.JSONString {
+				_, jsonOptions := getAndParseTagIndex(sf.t, sf.i, "json")
+
+				if isSlice {
+					report(f, false, "slices of %s can't be represented in JS, as not all %s values fit in a JS number and the json string option doesn't apply to slices; use a slice of strings, or of a smaller type such as int32 if the values fit", goType, goType)
+					continue fields
+				}
+				if _, ok := jsonOptions["string"]; !ok {
+					report(f, true, "%s is a string in JS, as not all of its values fit in a JS number, so the field has to be a string in JSON too; add the json string option, e.g. `json:\",string\"`, which makes API clients send and receive it as a string, or use a smaller type such as int32 if its values fit", goType)
+					continue fields
+				}
+			}
+		}
+
+		jsType := mapping.JS
+		flowType := mapping.Flow
+		sqlType := mapping.SQL
+		swaggerType := &SwaggerType{}
+		*swaggerType = *mapping.Swagger
+
+		var jsEnums []string
+		var flowEnums []string
+		var swaggerEnums []interface{}
+
+		if len(enums) > 0 {
+			switch jsType {
+			case "string":
+				jsEnums = make([]string, len(enums))
+				flowEnums = make([]string, len(enums))
+				swaggerEnums = make([]interface{}, len(enums))
+				for i := range enums {
+					jsEnums[i] = "'" + enums[i].Value + "'"
+					flowEnums[i] = "'" + enums[i].Value + "'"
+					swaggerEnums[i] = enums[i].Value
+				}
+			case "number":
+				jsEnums = make([]string, len(enums))
+				flowEnums = make([]string, len(enums))
+				swaggerEnums = make([]interface{}, len(enums))
+				for i := range enums {
+					jsEnums[i] = enums[i].Value
+					flowEnums[i] = enums[i].Value
+					swaggerEnums[i] = enums[i].Value
+				}
+			default:
+				report(f, true, "got enum values but can't make js type for %q", jsType)
+				continue fields
+			}
+		}
+
+		if len(jsEnums) > 0 {
+			jsType = typeName + gf.GoName
+			flowType = "global_db_" + typeName + gf.GoName
+		}
+
+		if len(swaggerEnums) > 0 {
+			swaggerType.Enum = swaggerEnums
+		}
+
+		// types from the models package itself can't be qualified in the code
+		// generated into it
+		gf.GoType = goType
+		if n, ok := ft.(*types.Named); ok && !jsonb && n.Obj().Pkg() == namedType.Obj().Pkg() {
+			gf.GoType = n.Obj().Name()
+		} else if ok && !jsonb {
+			gf.GoImport = n.Obj().Pkg().Path()
+		}
+
+		// string enums in typed models get a type of their own in the enum
+		// package, which the field has to be declared with to have its values
+		// checked when it's compiled
+		if len(enums) > 0 && !jsonb && mapType == "string" {
+			enumType := strings.ToLower(typeName) + "enum." + gf.GoName
+
+			switch {
+			case goType == enumType && !typed:
+				report(f, false, "%s is only generated for models with \"@apigen typedenums\"", enumType)
+				continue fields
+			case goType == enumType:
+				gf.EnumType = enumType
+			case typed:
+				warnings = append(warnings, Diagnostic{
+					Pos:     src.fieldPosition(f, false),
+					Model:   typeName,
+					Field:   f.Name(),
+					Message: fmt.Sprintf("declare %s as %s to have its values checked when it's compiled", f.Name(), enumType),
+				})
+			}
+
+			gf.TypedEnum = typed
+		}
+
+		if defaultValue != "" {
+			d, err := parseDefault(defaultValue, mapType, gf.GoType, mapping, isSlice, isPointer, enums)
+			if err != nil {
+				report(f, true, "bad default tag: %v", err)
+				continue fields
+			}
+
+			// typed enums have constants for their values
+			if gf.EnumType != "" {
+				d.Go = gf.EnumType + enums.GetByValue(defaultValue).GoName
+			}
+
+			gf.Default = d
+		}
+
+		gf.CompareType = mapType
+		if declared && st.types[goType].Equal != "" {
+			gf.CompareType = goType
+		}
+		if jsonb {
+			gf.JSON = true
+			gf.CompareType = "any"
+		}
+
+		gf.JSType = jsType
+		gf.FlowType = flowType
+		gf.SwaggerType = swaggerType
+		gf.SQLType = sqlType
+
+		if isPointer {
+			gf.IsNull = true
+			gf.GoType = "*" + gf.GoType
+			if gf.CompareType != "any" {
+				gf.CompareType = "*" + gf.CompareType
+			}
+			gf.JSType = "?" + gf.JSType
+			gf.FlowType = "?" + gf.FlowType
+			gf.SwaggerType.Nullable = true
+		}
+		// nil byte slices are stored as nulls, and encoded as nulls in JSON
+		if mapType == "[]byte" && !isPointer && !isSlice {
+			gf.IsNull = true
+			gf.JSType = "?" + gf.JSType
+			gf.FlowType = "?" + gf.FlowType
+			gf.SwaggerType.Nullable = true
+		}
+		if isSlice {
+			gf.Array = true
+			gf.GoType = "[]" + gf.GoType
+			// the slice helpers in modelutil only take the exact types
+			if gf.CompareType != "any" && mapType == goType {
+				gf.CompareType = "[]" + gf.CompareType
+			} else {
+				gf.CompareType = "any"
+			}
+			gf.JSType = "$ReadOnlyArray<" + gf.JSType + ">"
+			gf.FlowType = "$ReadOnlyArray<" + gf.FlowType + ">"
+			gf.SwaggerType = &SwaggerType{Type: "array", Items: gf.SwaggerType}
+		}
+
+		if validation != nil {
+			if err := validation.build(mapType, mapping, isSlice, isPointer, mapType != goType, "validate"+typeName+gf.GoName+"Pattern", "validate"+typeName+"Email"); err != nil {
+				report(f, true, "bad validate tag: %v", err)
+				continue fields
+			}
+
+			gf.Validate = validation
+			gf.SwaggerType = validation.describe(gf.SwaggerType, mapType, isSlice)
+		}
+
+		// the Swagger type is shared with the filters, so it's copied before
+		// the default is added to it
+		if gf.Default != nil {
+			t := *gf.SwaggerType
+			t.Default = gf.Default.swaggerValue(mapping)
+			gf.SwaggerType = &t
+		}
+
+		switch {
+		case isSlice && isPointer:
+			gf.ScanType = mapping.PointerSliceScan
+		case isSlice:
+			gf.ScanType = mapping.SliceScan
+		case !isPointer:
+			gf.FormatType = mapping.Format
+			if gf.FormatType == "" {
+				gf.FormatType = "%v"
+			}
+			gf.CSV = mapping.CSV
+		}
+
+		for range apiTagOptions["userFilter"] {
+			hasUserFilter = true
+		}
+
+		for _, opts := range apiTagOptions["specialOrder"] {
+			o := SpecialOrder{APIName: apiName, GoName: f.Name()}
+
+			if len(opts) > 0 && opts[0] != "" {
+				o.APIName = opts[0]
+			}
+			if len(opts) > 1 && opts[1] != "" {
+				o.GoName = opts[1]
+			}
+
+			specialOrders = append(specialOrders, o)
+		}
+
+		for _, opts := range apiTagOptions["specialFilter"] {
+			goName := st.lowerCamelUpperCamelCaps.String(apiName)
+			if len(opts) > 0 && opts[0] != "" {
+				goName = opts[0]
+			}
+
+			name := apiName
+			if len(opts) > 1 && opts[1] != "" {
+				name = opts[1]
+			}
+
+			f := Filter{
+				Operator:    "=",
+				Name:        name,
+				GoName:      goName,
+				JSType:      jsType,
+				FlowType:    flowType,
+				SwaggerType: swaggerType,
+			}
+
+			switch ft := ft.(type) {
+			case *types.Basic:
+				f.GoType = "*" + ft.String()
+			case *types.Named:
+				f.GoType = "*" + ft.Obj().Pkg().Name() + "." + ft.Obj().Name()
+			case *types.Alias:
+				f.GoType = "*" + ft.Obj().Pkg().Name() + "." + ft.Obj().Name()
+			}
+
+			switch f.Operator {
+			case "is_null", "is_not_null":
+				f.GoType = "*bool"
+				f.JSType = "boolean"
+				f.FlowType = "boolean"
+				f.SwaggerType = &SwaggerType{Type: "string", Enum: []interface{}{"true", "false"}}
+			case "in", "not_in", "@>", "!@>", "<@", "!<@", "&&", "!&&":
+				f.GoType = "[]" + strings.TrimPrefix(f.GoType, "*")
+				f.JSType = "$ReadOnlyArray<" + strings.TrimPrefix(f.JSType, "?") + ">"
+				f.FlowType = "$ReadOnlyArray<" + strings.TrimPrefix(f.FlowType, "?") + ">"
+				f.SwaggerType = &SwaggerType{Type: "array", Items: f.SwaggerType}
+			}
+
+			specialFilters = append(specialFilters, f)
+		}
+
+		filterOptions := apiTagOptions["filter"]
+
+		if len(filterOptions) == 0 || filterOptions[0][0] == "defaults" {
+			var others [][]string
+			if len(filterOptions) > 1 {
+				others = filterOptions[1:]
+			}
+
+			var operators [][]string
+			switch {
+			case isSlice && isPointer:
+			case isSlice:
+				operators = [][]string{mapping.SliceFilters}
+			case isPointer:
+				operators = [][]string{mapping.Filters, mapping.PointerFilters}
+			default:
+				operators = [][]string{mapping.Filters}
+			}
+
+			filterOptions = nil
+			for _, a := range operators {
+				for _, op := range a {
+					filterOptions = append(filterOptions, []string{op})
+				}
+			}
+
+			if isPointer {
+				filterOptions = append(filterOptions, []string{"is_null"}, []string{"is_not_null"})
+			}
+
+			if len(swaggerEnums) > 0 {
+				filterOptions = append(filterOptions, []string{"in"}, []string{"not_in"})
+			}
+
+			filterOptions = append(filterOptions, others...)
+		}
+
+		for _, opts := range filterOptions {
+			operator := "="
+			if len(opts) > 0 && opts[0] != "" {
+				operator = opts[0]
+			}
+
+			jsName := apiName
+			goName := f.Name()
+			filterJSType := jsType
+			filterFlowType := flowType
+			filterSwaggerType := swaggerType
+			switch operator {
+			case "!=":
+				jsName = jsName + "Ne"
+				goName = goName + "Ne"
+			case "<":
+				jsName = jsName + "Lt"
+				goName = goName + "Lt"
+			case "<=":
+				jsName = jsName + "Lte"
+				goName = goName + "Lte"
+			case "is_null_or_less_than":
+				jsName = jsName + "IsNullOrLessThan"
+				goName = goName + "IsNullOrLessThan"
+			case "is_null_or_less_than_or_equal_to":
+				jsName = jsName + "IsNullOrLessThanOrEqualTo"
+				goName = goName + "IsNullOrLessThanOrEqualTo"
+			case ">":
+				jsName = jsName + "Gt"
+				goName = goName + "Gt"
+			case ">=":
+				jsName = jsName + "Gte"
+				goName = goName + "Gte"
+			case "is_null_or_greater_than":
+				jsName = jsName + "IsNullOrGreaterThan"
+				goName = goName + "IsNullOrGreaterThan"
+			case "is_null_or_greater_than_or_equal_to":
+				jsName = jsName + "IsNullOrGreaterThanOrEqualTo"
+				goName = goName + "IsNullOrGreaterThanOrEqualTo"
+			case "in":
+				jsName = jsName + "In"
+				goName = goName + "In"
+			case "not_in":
+				jsName = jsName + "NotIn"
+				goName = goName + "NotIn"
+			case "is_null":
+				jsName = jsName + "IsNull"
+				goName = goName + "IsNull"
+				filterJSType = "boolean"
+				filterFlowType = "boolean"
+				filterSwaggerType = &SwaggerType{Type: "string", Enum: []interface{}{"true", "false"}}
+			case "is_not_null":
+				jsName = jsName + "IsNotNull"
+				goName = goName + "IsNotNull"
+				filterJSType = "boolean"
+				filterFlowType = "boolean"
+				filterSwaggerType = &SwaggerType{Type: "string", Enum: []interface{}{"true", "false"}}
+			case "@@":
+				jsName = jsName + "Match"
+				goName = goName + "Match"
+			case "contains":
+				jsName = jsName + "Contains"
+				goName = goName + "Contains"
+			case "prefix":
+				jsName = jsName + "StartsWith"
+				goName = goName + "StartsWith"
+			case "@>":
+				jsName = jsName + "SupersetOf"
+				goName = goName + "SupersetOf"
+			case "!@>":
+				jsName = jsName + "NotSupersetOf"
+				goName = goName + "NotSupersetOf"
+			case "<@":
+				jsName = jsName + "SubsetOf"
+				goName = goName + "SubsetOf"
+			case "!<@":
+				jsName = jsName + "NotSubsetOf"
+				goName = goName + "NotSubsetOf"
+			case "&&":
+				jsName = jsName + "Intersects"
+				goName = goName + "Intersects"
+			case "!&&":
+				jsName = jsName + "NotIntersects"
+				goName = goName + "NotIntersects"
+			case "sha256":
+				jsName = jsName + "Sha256"
+				goName = goName + "SHA256"
+				filterJSType = "string"
+				filterFlowType = "string"
+				filterSwaggerType = &SwaggerType{Type: "string"}
+			}
+			if len(opts) > 1 && opts[1] != "" {
+				jsName = opts[1]
+				goName = opts[1]
+			}
+
+			gff := Filter{
+				Operator:    operator,
+				Name:        jsName,
+				GoName:      goName,
+				JSType:      filterJSType,
+				FlowType:    filterFlowType,
+				SwaggerType: filterSwaggerType,
+			}
+
+			switch ft := ft.(type) {
+			case *types.Basic:
+				gff.GoType = "*" + ft.String()
+			case *types.Named:
+				gff.GoType = "*" + ft.Obj().Pkg().Name() + "." + ft.Obj().Name()
+			case *types.Alias:
+				gff.GoType = "*" + ft.Obj().Pkg().Name() + "." + ft.Obj().Name()
+			}
+
+			// the apifilter packages are imported by the models package, so
+			// they can't refer to types declared in it; named types are
+			// filtered on as their underlying type instead
+			if mapType != goType {
+				gff.GoType = "*" + mapType
+			} else if n, ok := ft.(*types.Named); ok && n.Obj().Pkg() == namedType.Obj().Pkg() && operator != "is_null" && operator != "is_not_null" {
+				report(f, true, "can't filter on %s with %q, as it's declared in the models package", gf.GoType, operator)
+				continue fields
+			}
+
+			switch operator {
+			case "is_null", "is_not_null":
+				gff.GoType = "*bool"
+				gff.JSType = "boolean"
+				gff.FlowType = "boolean"
+			case "sha256":
+				gff.GoType = "*string"
+			case "in", "not_in", "@>", "!@>", "<@", "!<@", "&&", "!&&":
+				gff.GoType = "[]" + strings.TrimPrefix(gff.GoType, "*")
+				gff.JSType = "$ReadOnlyArray<" + strings.TrimPrefix(gff.JSType, "?") + ">"
+				gff.FlowType = "$ReadOnlyArray<" + strings.TrimPrefix(gff.FlowType, "?") + ">"
+			default:
+				gff.JSONString = mapping.JSONString
+			}
+
+			gf.Filters = append(gf.Filters, gff)
+		}
+
+		for _, opts := range apiTagOptions["ref"] {
+			var modelName, fieldName string
+			switch len(opts) {
+			case 1:
+				modelName = opts[0]
+				fieldName = "ID"
+			case 2:
+				modelName = opts[0]
+				fieldName = opts[1]
+			default:
+				report(f, true, "bad ref option (%v)", opts)
+				continue fields
+			}
+
+			gf.APIRefs = append(gf.APIRefs, APIRef{
+				ModelName: modelName,
+				FieldName: fieldName,
+			})
+		}
+
+		if f.Exported() {
+			fields = append(fields, gf)
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	plural := inflect.Camelize(strings.Join(words, "_"))
+
+	if directives.plural != "" {
+		plural = directives.plural
+		lowerPlural = st.upperCamelLowerCamel.String(plural)
+		lowerSnakePlural = st.upperCamelLowerSnake.String(plural)
+	}
+	if directives.lowerPlural != "" {
+		lowerPlural = directives.lowerPlural
+	}
+	if directives.table != "" {
+		sqlTableName = directives.table
+	}
+
+	hasAPINoAudit = hasAPINoAudit || directives.noAudit
+	hasAPINoSearch = hasAPINoSearch || directives.noSearch
+	hasAPINoGet = hasAPINoGet || directives.noGet
+	hasAPINoCreate = hasAPINoCreate || directives.noCreate
+	hasAPINoUpdate = hasAPINoUpdate || directives.noUpdate
+	hasSQLFindOne = hasSQLFindOne || directives.sql["findOne"]
+	hasSQLFindOneByID = hasSQLFindOneByID || directives.sql["findOneByID"]
+	hasSQLFindMultiple = hasSQLFindMultiple || directives.sql["findMultiple"]
+	hasSQLCreate = hasSQLCreate || directives.sql["create"]
+	hasSQLSave = hasSQLSave || directives.sql["save"]
+
+	var processes []string
+
+	for _, f := range fields {
+		if !strings.HasSuffix(f.GoName, "JobID") {
+			continue
+		}
+
+		processName := strings.TrimSuffix(f.GoName, "JobID")
+
+		// the status can be a typed enum, so it's matched on the type it's
+		// compared as rather than its own
+		if status := fields.GetByName(processName + "Status"); status == nil || status.CompareType != "string" {
+			continue
+		}
+
+		if !fields.HasFieldsWithNamesAndTypes([][2]string{
+			{processName + "JobID", "*int"},
+			{processName + "StartedAt", "*time.Time"},
+			{processName + "Deadline", "*time.Time"},
+			{processName + "FailureMessage", "string"},
+			{processName + "CompletedAt", "*time.Time"},
+		}) {
+			continue
+		}
+
+		if !fields.GetByName(processName + "Status").HasEnumValues([]string{
+			"in-progress",
+			"completed",
+			"failed",
+		}) {
+			continue
+		}
+
+		processes = append(processes, processName)
+	}
+
+	return &Model{
+		Singular:           typeName,
+		Plural:             plural,
+		LowerPlural:        lowerPlural,
+		LowerSnakePlural:   lowerSnakePlural,
+		SQLTableName:       sqlTableName,
+		Fields:             fields,
+		IDField:            fields.GetByName("ID"),
+		VersionField:       fields.GetByName("Version"),
+		Processes:          processes,
+		SpecialOrders:      specialOrders,
+		SpecialFilters:     specialFilters,
+		HasID:              hasID,
+		HasVersion:         hasVersion,
+		HasCreatedAt:       hasCreatedAt,
+		HasUpdatedAt:       hasUpdatedAt,
+		HasCreatorID:       hasCreatorID,
+		HasUpdaterID:       hasUpdaterID,
+		HasAudit:           hasAPINoAudit == false,
+		HasUserFilter:      hasUserFilter,
+		HasAPISearch:       hasAPINoSearch == false,
+		HasAPIGet:          hasAPINoGet == false,
+		HasAPICreate:       hasAPINoCreate == false && hasCreatedAt,
+		HasAPIUpdate:       hasAPINoUpdate == false && hasUpdatedAt,
+		HasSQLFindOne:      hasSQLFindOne,
+		HasSQLFindOneByID:  hasSQLFindOneByID,
+		HasSQLFindMultiple: hasSQLFindMultiple,
+		HasSQLCreate:       hasSQLCreate,
+		HasSQLSave:         hasSQLSave,
+		warnings:           warnings,
+	}, nil
+}
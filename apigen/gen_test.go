@@ -0,0 +1,643 @@
+package apigen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	for _, testCase := range []struct {
+		input string
+		words []string
+	}{
+		{"OneTwoThree", []string{"One", "Two", "Three"}},
+		{"FNNAPIUser", []string{"FNN", "API", "User"}},
+		{"WBISIM", []string{"WBI", "SIM"}},
+	} {
+		t.Run(testCase.input, func(t *testing.T) {
+			assert.Equal(t, testCase.words, builtinSettings.splitWords(testCase.input))
+		})
+	}
+}
+
+func TestParseModelDirectives(t *testing.T) {
+	d, err := parseModelDirectives("@apigen table=service_orders plural=ServiceOrders noaudit nocreate typedenums\nsql=findOne,save")
+	assert.NoError(t, err)
+	assert.Equal(t, &modelDirectives{
+		table:      "service_orders",
+		plural:     "ServiceOrders",
+		noAudit:    true,
+		noCreate:   true,
+		typedEnums: true,
+		sql:        map[string]bool{"findOne": true, "save": true},
+	}, d)
+
+	d, err = parseModelDirectives("Widget is a thing.")
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	for _, s := range []string{"@apigen tabel=x", "@apigen table", "@apigen noaudit=yes", "@apigen sql=findAll"} {
+		_, err := parseModelDirectives(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestFlattenFields(t *testing.T) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "models.go", `package models
+
+type A struct {
+	X int
+	Y int
+}
+
+type B struct {
+	Y int
+	Z int
+}
+
+type C struct {
+	W int
+}
+
+type D struct {
+	V int
+}
+
+type M struct {
+	A
+	B
+	Z string
+	*C
+	D `+"`api:\"-\"`"+`
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := new(types.Config).Check("models", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var problems []string
+	report := func(f *types.Var, tag bool, format string, args ...interface{}) {
+		problems = append(problems, f.Name()+": "+fmt.Sprintf(format, args...))
+	}
+
+	var names []string
+	for _, sf := range flattenFields(pkg.Scope().Lookup("M").Type().Underlying().(*types.Struct), report) {
+		names = append(names, sf.t.Field(sf.i).Name())
+	}
+
+	assert.Equal(t, []string{"X", "Y", "Z"}, names)
+	assert.Equal(t, []string{
+		"C: embedded struct pointers aren't supported; embed C by value",
+		"Y: field is ambiguous; Y is also promoted from another embedded struct",
+	}, problems)
+}
+
+func TestMakeModelNamedTypes(t *testing.T) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "models.go", `package models
+
+type Status string
+
+type Grams int
+
+type Money struct{ cents int }
+
+func (m *Money) Scan(src interface{}) error { return nil }
+func (m Money) Value() (interface{}, error) { return nil, nil }
+func (m Money) MarshalJSON() ([]byte, error) { return nil, nil }
+
+type Point struct{ X, Y int }
+
+type Size struct {
+	Width  int
+	Height int
+	Note   string `+"`json:\"note,omitempty\"`"+`
+}
+
+type Widget struct {
+	ID     int
+	Status Status
+	Weight *Grams
+	Labels []Status
+	Price  Money
+	Size   Size
+	Parts  []Size
+	Meta   map[string]*Money
+}
+
+type Node struct {
+	Name     string
+	Children []Node
+}
+
+type Tree struct {
+	ID   int
+	Root Node
+}
+
+type Place [2]float64
+
+type Broken struct {
+	ID       int
+	Location Point
+}
+
+type Unknown struct {
+	ID    int
+	Where Place
+}
+
+type Partial struct {
+	ID    int
+	Where Place
+}
+
+type Counter struct {
+	ID    int
+	Count int64
+	Total int64 `+"`json:\",string\"`"+`
+}
+
+type Tally struct {
+	ID     int
+	Counts []int64
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := new(types.Config).Check("models", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := newSettings(&Config{Types: map[string]TypeMapping{
+		"models.Money": {JS: "string", Flow: "string", SQL: "numeric", Swagger: &SwaggerType{Type: "string"}},
+		"models.Point": {JS: "string", Flow: "string", SQL: "point", Swagger: &SwaggerType{Type: "string"}, Equal: "$1 == $2", Format: "%v"},
+		"models.Grams": {SQL: "bigint"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeFor := func(name string) (*Model, error) {
+		named := pkg.Scope().Lookup(name).Type().(*types.Named)
+		return st.makeModel(nil, nil, name, named, named.Underlying().(*types.Struct))
+	}
+
+	model, err := makeFor("Widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range []struct {
+		name, goType, jsType, sqlType, compareType string
+	}{
+		{"Status", "Status", "string", "text", "string"},
+		{"Weight", "*Grams", "?number", "bigint", "*int"},
+		{"Labels", "[]Status", "$ReadOnlyArray<string>", "text", "any"},
+		{"Price", "Money", "string", "numeric", "models.Money"},
+		{"Size", "Size", "{| width: number, height: number, note?: string |}", "jsonb", "any"},
+		{"Parts", "[]Size", "?$ReadOnlyArray<{| width: number, height: number, note?: string |}>", "jsonb", "any"},
+		{"Meta", "map[string]*Money", "?{ [key: string]: ?string }", "jsonb", "any"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			f := model.Fields.GetByName(testCase.name)
+			if !assert.NotNil(t, f) {
+				return
+			}
+
+			assert.Equal(t, testCase.goType, f.GoType)
+			assert.Equal(t, testCase.jsType, f.JSType)
+			assert.Equal(t, testCase.sqlType, f.SQLType)
+			assert.Equal(t, testCase.compareType, f.CompareType)
+		})
+	}
+
+	_, err = makeFor("Broken")
+	assert.EqualError(t, err, "Broken.Location: models.Point is declared in the config's types, but doesn't implement sql.Scanner and driver.Valuer")
+
+	_, err = makeFor("Tree")
+	assert.EqualError(t, err, "Tree.Root: Children: models.Node refers to itself, so it can't be described as json")
+
+	_, err = makeFor("Unknown")
+	assert.EqualError(t, err, "Unknown.Where: models.Place isn't a type apigen knows about; declare it in the config's types")
+
+	st.types = st.types.with(map[string]TypeMapping{"models.Place": {JS: "string", SQL: "text"}})
+
+	_, err = makeFor("Partial")
+	assert.EqualError(t, err, "Partial.Where: no flow, swagger declared for models.Place in the config's types")
+
+	_, err = makeFor("Counter")
+	assert.EqualError(t, err, "Counter.Count: int64 is a string in JS, as not all of its values fit in a JS number, so the field has to be a string in JSON too; add the json string option, e.g. `json:\",string\"`, which makes API clients send and receive it as a string, or use a smaller type such as int32 if its values fit")
+
+	_, err = makeFor("Tally")
+	assert.EqualError(t, err, "Tally.Counts: slices of int64 can't be represented in JS, as not all int64 values fit in a JS number and the json string option doesn't apply to slices; use a slice of strings, or of a smaller type such as int32 if the values fit")
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// typedEnumWidget type checks a models package with a Widget model in it,
+// which can import a widgetenum package and the standard library, and returns
+// the Widget type.
+func typedEnumWidget(t *testing.T, enumSrc, modelsSrc string) *types.Named {
+	t.Helper()
+
+	fset := token.NewFileSet()
+
+	ef, err := parser.ParseFile(fset, "widgetenum.go", enumSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enumPkg, err := new(types.Config).Check("models/modelenum/widgetenum", fset, []*ast.File{ef}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(fset, "models.go", modelsSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if path == enumPkg.Path() {
+			return enumPkg, nil
+		}
+		return importer.Default().Import(path)
+	})}
+	pkg, err := conf.Check("models", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pkg.Scope().Lookup("Widget").Type().(*types.Named)
+}
+
+func TestMakeModelTypedEnums(t *testing.T) {
+	named := typedEnumWidget(t, `package widgetenum
+
+type Status string
+
+type FulfilmentStatus string
+`, `package models
+
+import (
+	"time"
+
+	"models/modelenum/widgetenum"
+)
+
+type Widget struct {
+	ID     int
+	Status widgetenum.Status `+"`enum:\"|on|off\" default:\"on\"`"+`
+	Colour string            `+"`enum:\"|red|blue\"`"+`
+	Size   int               `+"`enum:\"|1|2\"`"+`
+
+	FulfilmentStatus         widgetenum.FulfilmentStatus `+"`enum:\"|in-progress|completed|failed\"`"+`
+	FulfilmentJobID          *int
+	FulfilmentStartedAt      *time.Time
+	FulfilmentDeadline       *time.Time
+	FulfilmentFailureMessage string
+	FulfilmentCompletedAt    *time.Time
+}
+`)
+
+	model, err := builtinSettings.makeModel(nil, &modelDirectives{typedEnums: true}, "Widget", named, named.Underlying().(*types.Struct))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// processes are found with a typed enum for their status
+	assert.Equal(t, []string{"Fulfilment"}, model.Processes)
+
+	status := model.Fields.GetByName("Status")
+	assert.True(t, status.TypedEnum)
+	assert.Equal(t, "widgetenum.Status", status.EnumType)
+	assert.Equal(t, "widgetenum.StatusOn", status.Default.Go)
+
+	colour := model.Fields.GetByName("Colour")
+	assert.True(t, colour.TypedEnum)
+	assert.Equal(t, "", colour.EnumType)
+
+	// only string enums get types
+	assert.False(t, model.Fields.GetByName("Size").TypedEnum)
+
+	if assert.Len(t, model.warnings, 1) {
+		assert.Equal(t, "declare Colour as widgetenum.Colour to have its values checked when it's compiled", model.warnings[0].Message)
+	}
+
+	_, err = builtinSettings.makeModel(nil, nil, "Widget", named, named.Underlying().(*types.Struct))
+	assert.EqualError(t, err, "Widget.Status: widgetenum.Status is only generated for models with \"@apigen typedenums\"\nWidget.FulfilmentStatus: widgetenum.FulfilmentStatus is only generated for models with \"@apigen typedenums\"")
+}
+
+func TestTypedEnumZeroValues(t *testing.T) {
+	named := typedEnumWidget(t, `package widgetenum
+
+type Status string
+
+type Colour string
+
+type Channels string
+`, `package models
+
+import "models/modelenum/widgetenum"
+
+type Widget struct {
+	ID       int
+	Status   widgetenum.Status     `+"`enum:\"|on|off\" validate:\"required\"`"+`
+	Colour   widgetenum.Colour     `+"`enum:\"|red|blue\"`"+`
+	Channels []widgetenum.Channels `+"`enum:\"|email|post\"`"+`
+}
+`)
+
+	model, err := builtinSettings.makeModel(nil, &modelDirectives{typedEnums: true}, "Widget", named, named.Underlying().(*types.Struct))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	for _, w := range NewEnumGenerator(dir, nil).Model(model) {
+		d, err := renderWriter(logrus.NewEntry(logger), w, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mustNoError(t, os.MkdirAll(filepath.Dir(w.File()), 0755))
+		mustNoError(t, ioutil.WriteFile(w.File(), d, 0644))
+	}
+
+	mustNoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module models\n\ngo 1.22\n"), 0644))
+
+	// the optional fields of a zero valued model survive being encoded and
+	// stored, but the required one doesn't
+	mustNoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"models/modelenum/widgetenum"
+)
+
+type Widget struct {
+	Colour   widgetenum.Colour
+	Channels []widgetenum.Channels
+}
+
+func main() {
+	var zero Widget
+
+	d, err := json.Marshal(zero)
+	fmt.Println(string(d), err)
+
+	var decoded Widget
+	fmt.Println(json.Unmarshal(d, &decoded), decoded.Colour == "")
+
+	v, err := zero.Colour.Value()
+	fmt.Printf("%q %v\n", v, err)
+
+	colour := widgetenum.ColourRed
+	fmt.Println(colour.Scan(nil), colour == "")
+	fmt.Println(colour.Scan(v), colour == "")
+
+	var status widgetenum.Status
+	_, err = status.Value()
+	fmt.Println(err)
+	fmt.Println(status.Scan(nil))
+	_, err = json.Marshal(status)
+	fmt.Println(err != nil)
+}
+`), 0644))
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	assert.Equal(t, `{"Colour":"","Channels":null} <nil>
+<nil> true
+"" <nil>
+<nil> true
+<nil> true
+Status.Value: expected one of [on off] but got ""
+Status.Scan: expected one of [on off] but got ""
+true
+`, string(out))
+}
+
+func TestEqualExpr(t *testing.T) {
+	for _, testCase := range []struct {
+		typ, equal, notEqual string
+	}{
+		{"int", "a == b", "a != b"},
+		{"*string", "((a == nil && b == nil) || (a != nil && b != nil && *a == *b))", "((a == nil && b != nil) || (a != nil && b == nil) || (a != nil && b != nil && *a != *b))"},
+		{"time.Time", "a.Equal(b)", "!a.Equal(b)"},
+		{"*civil.Date", "((a == nil && b == nil) || (a != nil && b != nil && a.On(*b)))", "((a == nil && b != nil) || (a != nil && b == nil) || (a != nil && b != nil && !a.On(*b)))"},
+		{"[]uuid.UUID", "modelutil.EqualUUIDSlice(a, b)", "!modelutil.EqualUUIDSlice(a, b)"},
+		{"[]int", "modelutil.Equal(a, b)", "!modelutil.Equal(a, b)"},
+		{"[]byte", "bytes.Equal(a, b)", "!bytes.Equal(a, b)"},
+		{"any", "modelutil.Equal(a, b)", "!modelutil.Equal(a, b)"},
+	} {
+		t.Run(testCase.typ, func(t *testing.T) {
+			equal, err := builtinTypes.equalExpr("a", "b", testCase.typ, true)
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.equal, equal)
+			}
+
+			notEqual, err := builtinTypes.equalExpr("a", "b", testCase.typ, false)
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.notEqual, notEqual)
+			}
+		})
+	}
+
+	_, err := builtinTypes.equalExpr("a", "b", "complex128", true)
+	assert.EqualError(t, err, "equalExpr: no way of comparing values of type complex128")
+
+	// types declared without an equal expression are compared with
+	// modelutil.Equal, even through pointers
+	r := builtinTypes.with(map[string]TypeMapping{"models.Money": {SQL: "numeric"}})
+	for typ, expected := range map[string]string{
+		"models.Money":  "!modelutil.Equal(a, b)",
+		"*models.Money": "!modelutil.Equal(a, b)",
+	} {
+		notEqual, err := r.equalExpr("a", "b", typ, false)
+		if assert.NoError(t, err, typ) {
+			assert.Equal(t, expected, notEqual, typ)
+		}
+	}
+}
+
+func TestSettingsHash(t *testing.T) {
+	hashFor := func(c Config) string {
+		st, err := newSettings(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return st.hash
+	}
+
+	base := hashFor(Config{})
+	assert.Equal(t, base, hashFor(Config{}))
+
+	for name, c := range map[string]Config{
+		"UpperCaseOverrides": {UpperCaseOverrides: []string{"ID"}},
+		"IgnoreCreate":       {IgnoreCreate: []string{"ID"}},
+		"IgnoreUpdate":       {IgnoreUpdate: []string{"ID"}},
+		"TypedEnums":         {TypedEnums: true},
+		"Types":              {Types: map[string]TypeMapping{"models.Money": {SQL: "numeric"}}},
+	} {
+		assert.NotEqual(t, base, hashFor(c), name)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	for _, testCase := range []struct {
+		name, tag, mapType string
+		isSlice, isPointer bool
+		convert            bool
+		checks             []string
+		err                string
+	}{
+		{"required string", "required", "string", false, false, false, []string{`$1 == ""`}, ""},
+		{"required pointer", "required", "int", false, true, false, []string{"$1 == nil"}, ""},
+		{"required slice", "required", "uuid.UUID", true, false, false, []string{"len($1) == 0"}, ""},
+		{"string length", "min=2,max=8", "string", false, true, true, []string{"$1 != nil && utf8.RuneCountInString(string(*$1)) < 2", "$1 != nil && utf8.RuneCountInString(string(*$1)) > 8"}, ""},
+		{"number", "min=-1.5", "float64", false, false, false, []string{"$1 < -1.5"}, ""},
+		{"pattern with commas", "required,pattern=^[a-z]{1,3}$", "string", false, false, false, []string{`$1 == ""`, `$1 != "" && !p.MatchString($1)`}, ""},
+		{"email", "email", "string", false, false, false, []string{`$1 != "" && !e.MatchString($1)`}, ""},
+		{"pattern after email", "email,pattern=^[a-z,@.]+$", "string", false, false, false, []string{`$1 != "" && !p.MatchString($1)`, `$1 != "" && !e.MatchString($1)`}, ""},
+		{"required bool", "required", "bool", false, false, false, nil, "required doesn't apply to bool fields, as they don't have a zero value apigen knows about"},
+		{"fractional int", "max=1.5", "int", false, false, false, nil, "max for int fields has to be a whole number"},
+		{"pattern on slice", "pattern=^a", "string", true, false, false, nil, "pattern only applies to strings, not string"},
+		{"min on time", "min=1", "time.Time", false, false, false, nil, "min applies to strings, numbers, and slices, not time.Time"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			v, err := parseValidation(testCase.tag)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = v.build(testCase.mapType, builtinTypes[testCase.mapType], testCase.isSlice, testCase.isPointer, testCase.convert, "p", "e")
+			if testCase.err != "" {
+				assert.EqualError(t, err, testCase.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var checks []string
+			for _, c := range v.Checks {
+				checks = append(checks, c.Go)
+			}
+
+			assert.Equal(t, testCase.checks, checks)
+		})
+	}
+
+	v, err := parseValidation("min=1,max=3,email")
+	if assert.NoError(t, err) {
+		one, three := 1.0, 3.0
+		assert.Equal(t, &SwaggerType{Type: "string", Format: "email", MinLength: &one, MaxLength: &three}, v.describe(&SwaggerType{Type: "string"}, "string", false))
+		assert.Equal(t, &SwaggerType{Type: "number", Minimum: &one, Maximum: &three}, v.describe(&SwaggerType{Type: "number"}, "int", false))
+		assert.Equal(t, &SwaggerType{Type: "array", MinItems: &one, MaxItems: &three}, v.describe(&SwaggerType{Type: "array"}, "string", true))
+	}
+
+	for tag, message := range map[string]string{
+		"min=one":                     "min needs a number, e.g. min=1",
+		"required=true":               "required doesn't take a value",
+		"min=5,max=1":                 "min is greater than max",
+		"pattern=[":                   "pattern isn't a valid regular expression: error parsing regexp: missing closing ]: `[`",
+		"unique":                      `unknown rule "unique"`,
+		"pattern=^[A-Z0-9]+$,email":   "pattern has to be the last rule, as it takes the rest of the tag, but it's followed by email",
+		"pattern=^[a-z]{1,3}$,max=10": "pattern has to be the last rule, as it takes the rest of the tag, but it's followed by max=10",
+	} {
+		_, err := parseValidation(tag)
+		assert.EqualError(t, err, message, tag)
+	}
+}
+
+func TestParseDefault(t *testing.T) {
+	enums := EnumList{{Value: "pending"}, {Value: "shipped"}}
+
+	for _, testCase := range []struct {
+		name, tag, mapType, goType string
+		isSlice, isPointer         bool
+		enums                      EnumList
+		kind, goExpr, zero         string
+		err                        string
+	}{
+		{"string", "hello", "string", "string", false, false, nil, "literal", `"hello"`, `$1 == ""`, ""},
+		{"enum", "pending", "string", "OrderStatus", false, false, enums, "literal", `OrderStatus("pending")`, `$1 == ""`, ""},
+		{"int32 pointer", "3", "int32", "int32", false, true, nil, "literal", "int32(3)", "$1 == nil", ""},
+		{"now", "now", "time.Time", "time.Time", false, false, nil, "now", "now", "$1.IsZero()", ""},
+		{"uuid", "uuid", "uuid.UUID", "uuid.UUID", false, false, nil, "uuid", "uuid.Must(uuid.NewV4())", "$1 == uuid.Nil", ""},
+		{"decimal", "1.50", "decimal.Decimal", "decimal.Decimal", false, false, nil, "literal", `decimal.RequireFromString("1.50")`, "$1.IsZero()", ""},
+		{"duration", "5m", "time.Duration", "time.Duration", false, false, nil, "literal", "time.Duration(300000000000)", "$1 == 0", ""},
+		{"bad enum", "lost", "string", "string", false, false, enums, "", "", "", `"lost" isn't one of the enum values`},
+		{"out of range", "40000", "int16", "int16", false, false, nil, "", "", "", `"40000" isn't a valid int16`},
+		{"bool", "true", "bool", "bool", false, false, nil, "", "", "", "bool fields can't have a default, as false can't be told apart from no value; use a *bool instead"},
+		{"bool pointer", "false", "bool", "bool", false, true, nil, "literal", "bool(false)", "$1 == nil", ""},
+		{"slice", "a", "string", "string", true, false, nil, "", "", "", "slices can't have a default"},
+		{"now for a date", "now", "civil.Date", "civil.Date", false, false, nil, "", "", "", `civil.Date fields can't have a default of "now"`},
+		{"float", "0.50", "float64", "float64", false, false, nil, "literal", "0.5", "$1 == 0", ""},
+		{"float32 pointer", "1e3", "float32", "float32", false, true, nil, "literal", "float32(1000)", "$1 == nil", ""},
+		{"float NaN", "NaN", "float64", "float64", false, false, nil, "", "", "", `"NaN" isn't a valid float64`},
+		{"float Inf", "Inf", "float64", "float64", false, false, nil, "", "", "", `"Inf" isn't a valid float64`},
+		{"float hex", "0x1p-2", "float64", "float64", false, false, nil, "", "", "", `"0x1p-2" isn't a valid float64`},
+		{"float out of range", "1e39", "float32", "float32", false, false, nil, "", "", "", `"1e39" isn't a valid float32`},
+		{"decimal exponent", "-1.5e3", "decimal.Decimal", "decimal.Decimal", false, false, nil, "literal", `decimal.RequireFromString("-1.5e3")`, "$1.IsZero()", ""},
+		{"decimal NaN", "NaN", "decimal.Decimal", "decimal.Decimal", false, false, nil, "", "", "", `"NaN" isn't a valid decimal`},
+		{"decimal Inf", "Inf", "decimal.Decimal", "decimal.Decimal", false, false, nil, "", "", "", `"Inf" isn't a valid decimal`},
+		{"decimal hex", "0x1p-2", "decimal.Decimal", "decimal.Decimal", false, false, nil, "", "", "", `"0x1p-2" isn't a valid decimal`},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			d, err := parseDefault(testCase.tag, testCase.mapType, testCase.goType, builtinTypes[testCase.mapType], testCase.isSlice, testCase.isPointer, testCase.enums)
+			if testCase.err != "" {
+				assert.EqualError(t, err, testCase.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, testCase.kind, d.Kind)
+			assert.Equal(t, testCase.goExpr, d.Go)
+			assert.Equal(t, testCase.zero, d.Zero)
+		})
+	}
+}
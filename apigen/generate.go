@@ -0,0 +1,885 @@
+package apigen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Result describes the outcome of Generate.
+type Result struct {
+	// Files are the files produced by the writers that were run, in the order
+	// they were run.
+	Files []File
+	// OutOfDate lists the files that differ from their generated output, or
+	// are stale, when checking.
+	OutOfDate []string
+}
+
+// File is the output of a single writer.
+type File struct {
+	// Path is where the file is (or would be) written.
+	Path string
+	// Model is the name of the model the file was generated from, or "_" for
+	// files generated from all of the models in a package.
+	Model     string
+	Generator string
+	Writer    string
+	Language  string
+	// Content is the generated code. It's nil if the writer was skipped
+	// because the file on disk was already up to date.
+	Content []byte
+}
+
+// Generate loads the packages matching cfg.Patterns, finds the models in them,
+// and runs every generator over them. Problems with the models are returned
+// as a DiagnosticList.
+//
+// When several packages are given, aggregated outputs that they share (e.g.
+// global_db.js in a common Flow directory) cover the models of all of them.
+func Generate(ctx context.Context, cfg Config) (Result, error) {
+	r, err := newRun(&cfg)
+	if err != nil {
+		return Result{}, fmt.Errorf("Generate: %w", err)
+	}
+
+	pkgs, modelsByPackage, err := r.load(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := r.generate(ctx, pkgs, modelsByPackage); err != nil {
+		return r.result, fmt.Errorf("Generate: %w", err)
+	}
+
+	return r.result, nil
+}
+
+// Models loads the packages matching cfg.Patterns and returns the models found
+// in each of them, restricted to those matching the model part of cfg.Filters.
+// Problems with the models are returned as a DiagnosticList.
+func Models(ctx context.Context, cfg Config) ([]ModelsDocument, error) {
+	r, err := newRun(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Models: %w", err)
+	}
+
+	pkgs, modelsByPackage, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []ModelsDocument
+
+	for _, pkg := range pkgs {
+		cfg := r.cfg.forPackage(pkg.Types.Name(), pkg.Types.Path())
+
+		doc := ModelsDocument{
+			Version:          ModelsDocumentVersion,
+			PackageName:      cfg.PackageName,
+			ModelsImportPath: cfg.ModelsImportPath,
+			Models:           []*Model{},
+		}
+
+		for _, model := range modelsByPackage[pkg] {
+			if r.cfg.Filters.matchModel(model.Singular) {
+				doc.Models = append(doc.Models, model)
+			}
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// run holds the state of a single call to Generate or Models.
+type run struct {
+	cfg *Config
+	l   *logrus.Entry
+
+	m      sync.Mutex
+	result Result
+
+	staged staging
+
+	pkgConfig *packages.Config
+	parsed    int
+
+	// changed restricts the per-model writers to the named models; it's
+	// nil to run them for every model
+	changed map[string]bool
+}
+
+func newRun(cfg *Config) (*run, error) {
+	if cfg.Logger == nil {
+		cfg.Logger = logrus.StandardLogger()
+	}
+	if cfg.Jobs < 1 {
+		cfg.Jobs = runtime.NumCPU()
+	}
+	if cfg.Diffs == nil {
+		cfg.Diffs = ioutil.Discard
+	}
+
+	st, err := newSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.settings = st
+
+	return &run{cfg: cfg, l: logrus.NewEntry(cfg.Logger)}, nil
+}
+
+func (r *run) addOutOfDate(filename string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.result.OutOfDate = append(r.result.OutOfDate, filename)
+}
+
+// load loads the packages and finds the models in each of them.
+func (r *run) load(ctx context.Context) ([]*packages.Package, map[*packages.Package][]*Model, error) {
+	overlay, err := r.generatedOverlay(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't read generated files from manifests: %w", err)
+	}
+
+	// the configuration is kept so that watch mode loads the packages the
+	// same way every time
+	if r.pkgConfig == nil {
+		var im sync.Mutex
+
+		r.pkgConfig = &packages.Config{
+			Dir:  r.cfg.Dir,
+			Mode: packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps | packages.NeedFiles,
+			ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+				if r.cfg.Progress != nil {
+					im.Lock()
+					r.parsed++
+					fmt.Fprintf(r.cfg.Progress, "\r%d files", r.parsed)
+					im.Unlock()
+				}
+
+				return parser.ParseFile(fset, filename, src, parser.ParseComments)
+			},
+		}
+	}
+
+	r.pkgConfig.Context = ctx
+	r.pkgConfig.Overlay = overlay
+	r.parsed = 0
+
+	pkgs, err := packages.Load(r.pkgConfig, r.cfg.Patterns...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't load packages: %w", err)
+	}
+
+	if r.cfg.Progress != nil {
+		fmt.Fprintf(r.cfg.Progress, "\n")
+	}
+
+	var foundErrors = false
+
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			r.l.WithError(err).Error("error found in package")
+
+			foundErrors = true
+		}
+	})
+
+	if foundErrors && !r.cfg.AllowSourceErrors {
+		return nil, nil, fmt.Errorf("errors found in package(s)")
+	}
+
+	var diagnostics DiagnosticList
+
+	modelsByPackage := make(map[*packages.Package][]*Model)
+
+	// model names end up in shared namespaces like the global Flow types and
+	// the JS ducks, so they have to be unique across all of the packages
+	modelPackages := make(map[string]*packages.Package)
+
+	for _, pkg := range pkgs {
+		models, err := r.cfg.settings.findModels(pkg)
+		if err != nil {
+			var d DiagnosticList
+			if !errors.As(err, &d) {
+				return nil, nil, fmt.Errorf("%s: could not find models: %w", pkg.Types.Path(), err)
+			}
+
+			diagnostics = append(diagnostics, d...)
+		}
+
+		for _, model := range models {
+			for _, w := range model.warnings {
+				r.l.Warn(w.String())
+			}
+
+			if other, ok := modelPackages[model.Singular]; ok {
+				diagnostics = append(diagnostics, Diagnostic{
+					Pos:     pkg.Fset.Position(pkg.Types.Scope().Lookup(model.Singular).Pos()),
+					Model:   model.Singular,
+					Message: fmt.Sprintf("model name is also used in %s", other.Types.Path()),
+				})
+
+				continue
+			}
+
+			modelPackages[model.Singular] = pkg
+		}
+
+		modelsByPackage[pkg] = models
+	}
+
+	if len(diagnostics) > 0 {
+		diagnostics.sort()
+		return nil, nil, diagnostics
+	}
+
+	return pkgs, modelsByPackage, nil
+}
+
+// generate runs the generators over the models loaded from pkgs, then moves
+// their outputs into place and updates the manifests and caches.
+func (r *run) generate(ctx context.Context, pkgs []*packages.Package, modelsByPackage map[*packages.Package][]*Model) error {
+	// nothing is written until every package has been generated, so a
+	// failure part of the way through leaves the previous outputs alone
+	defer r.staged.discard()
+
+	var outputs []*packageOutput
+
+	goDirs := make(map[string]string)
+
+	for _, pkg := range pkgs {
+		p, err := r.prepare(pkg, modelsByPackage[pkg])
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkg.Types.Path(), err)
+		}
+
+		if other, ok := goDirs[p.goDir]; ok {
+			return fmt.Errorf("%s and %s would both generate go code in %s", other, pkg.Types.Path(), p.goDir)
+		}
+		goDirs[p.goDir] = pkg.Types.Path()
+
+		outputs = append(outputs, p)
+	}
+
+	for _, p := range outputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := r.generateModels(ctx, p); err != nil {
+			return fmt.Errorf("%s: %w", p.pkg.Types.Path(), err)
+		}
+	}
+
+	if err := r.generateAggregated(ctx, outputs); err != nil {
+		return err
+	}
+
+	for _, p := range outputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := r.generatePlugins(ctx, p); err != nil {
+			return fmt.Errorf("%s: %w", p.pkg.Types.Path(), err)
+		}
+	}
+
+	if err := r.staged.commit(); err != nil {
+		return err
+	}
+
+	// output roots can be shared between packages, so the manifests are
+	// updated from everything produced in the run at once
+	var roots, produced []string
+	for _, p := range outputs {
+		roots = append(roots, p.roots...)
+		produced = append(produced, p.produced...)
+	}
+
+	if err := r.syncManifests(r.l, roots, produced); err != nil {
+		return fmt.Errorf("could not update output manifests: %w", err)
+	}
+
+	if !r.cfg.Dry && !r.cfg.Check {
+		for _, p := range outputs {
+			if err := p.cache.save(p.produced); err != nil {
+				return fmt.Errorf("%s: could not save cache: %w", p.pkg.Types.Path(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// packageOutput is where and how the code for a single package of models is
+// generated.
+type packageOutput struct {
+	l      *logrus.Entry
+	pkg    *packages.Package
+	cfg    *Config
+	models []*Model
+
+	goDir      string
+	generators []generator
+	cache      *cache
+
+	// roots are the output roots that manifests are kept for, and produced
+	// is every file a writer would produce, including those skipped due to
+	// filters, so that the manifests don't mark them as stale
+	roots    []string
+	produced []string
+}
+
+// prepare works out the output directories and generators for a package.
+func (r *run) prepare(pkg *packages.Package, models []*Model) (*packageOutput, error) {
+	cfg := r.cfg.forPackage(pkg.Types.Name(), pkg.Types.Path())
+
+	if cfg.PackageName == "" {
+		return nil, fmt.Errorf("couldn't determine package name")
+	}
+
+	goDir := r.goDirFor(pkg)
+	if goDir == "" {
+		return nil, fmt.Errorf("could not determine go directory")
+	}
+
+	jsDir := cfg.JSDir
+	if !filepath.IsAbs(jsDir) {
+		jsDir = filepath.Join(goDir, jsDir)
+	}
+
+	flowDir := cfg.FlowDir
+	if !filepath.IsAbs(flowDir) {
+		flowDir = filepath.Join(goDir, flowDir)
+	}
+
+	outputCache, err := loadCache(goDir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load cache: %w", err)
+	}
+
+	return &packageOutput{
+		l:          r.l.WithField("package", pkg.Types.Name()),
+		pkg:        pkg,
+		cfg:        cfg,
+		models:     models,
+		goDir:      goDir,
+		generators: newGenerators(goDir, jsDir, flowDir, cfg),
+		cache:      outputCache,
+		roots:      []string{goDir, jsDir, flowDir},
+	}, nil
+}
+
+// generateModels runs the writers for each of the models in a package.
+func (r *run) generateModels(ctx context.Context, p *packageOutput) error {
+	var jobs []*writerJob
+
+	for _, model := range p.models {
+		l := p.l.WithField("model", model.Singular)
+
+		for _, g := range p.generators {
+			g, ok := g.(generatorForModel)
+			if !ok {
+				continue
+			}
+
+			l := l.WithFields(logrus.Fields{
+				"mode":      "singular",
+				"generator": g.Name(),
+			})
+
+			for _, w := range g.Model(model) {
+				l := l.WithField("writer", w.Name())
+
+				p.produced = append(p.produced, w.File())
+
+				if !r.cfg.Filters.match(model.Singular, g.Name()+"/"+w.Name()) {
+					l.Debug("skipping writer due to not matching filter(s)")
+					continue
+				}
+
+				if r.changed != nil && !r.changed[model.Singular] {
+					l.Debug("skipping writer as its model hasn't changed")
+					continue
+				}
+
+				jobs = append(jobs, &writerJob{l: l, w: w, c: p.cache, model: model.Singular, generator: g.Name()})
+			}
+		}
+	}
+
+	return r.runWriters(ctx, jobs)
+}
+
+// generateAggregated runs the writers that cover all of the models in a
+// package. Packages whose aggregated outputs end up in the same file, e.g.
+// because they share a Flow directory, have their models merged into it.
+func (r *run) generateAggregated(ctx context.Context, outputs []*packageOutput) error {
+	type aggregate struct {
+		p      *packageOutput
+		g      generatorForModels
+		name   string
+		models []*Model
+	}
+
+	var order []string
+	aggregates := make(map[string]*aggregate)
+
+	for _, p := range outputs {
+		for _, g := range p.generators {
+			g, ok := g.(generatorForModels)
+			if !ok {
+				continue
+			}
+
+			for _, w := range g.Models(p.models) {
+				p.produced = append(p.produced, w.File())
+
+				a, ok := aggregates[w.File()]
+				if !ok {
+					a = &aggregate{p: p, g: g, name: w.Name()}
+					aggregates[w.File()] = a
+					order = append(order, w.File())
+				}
+
+				a.models = append(a.models, p.models...)
+			}
+		}
+	}
+
+	var jobs []*writerJob
+
+	for _, filename := range order {
+		a := aggregates[filename]
+
+		l := a.p.l.WithFields(logrus.Fields{
+			"mode":      "aggregated",
+			"generator": a.g.Name(),
+			"writer":    a.name,
+		})
+
+		if !r.cfg.Filters.match("_", a.g.Name()+"/"+a.name) {
+			l.Debug("skipping writer due to not matching filter(s)")
+			continue
+		}
+
+		for _, w := range a.g.Models(a.models) {
+			if w.File() == filename {
+				jobs = append(jobs, &writerJob{l: l, w: w, c: a.p.cache, model: "_", generator: a.g.Name(), afterModels: true})
+			}
+		}
+	}
+
+	return r.runWriters(ctx, jobs)
+}
+
+// generatePlugins runs the plugins over the models in a package.
+func (r *run) generatePlugins(ctx context.Context, p *packageOutput) error {
+	var jobs []*writerJob
+
+	for _, e := range p.cfg.Plugins {
+		g := NewPluginGenerator(p.goDir, p.cfg, e)
+
+		p.roots = append(p.roots, g.Dir())
+
+		l := p.l.WithFields(logrus.Fields{
+			"mode":      "plugin",
+			"generator": g.Name(),
+		})
+
+		writers, err := g.Models(ctx, p.models)
+		if err != nil {
+			return fmt.Errorf("could not run plugin: %w", err)
+		}
+
+		for _, w := range writers {
+			l := l.WithField("writer", w.Name())
+
+			p.produced = append(p.produced, w.File())
+
+			if !r.cfg.Filters.match("_", g.Name()+"/"+w.Name()) {
+				l.Debug("skipping writer due to not matching filter(s)")
+				continue
+			}
+
+			jobs = append(jobs, &writerJob{l: l, w: w, c: p.cache, model: "_", generator: g.Name(), afterModels: true})
+		}
+	}
+
+	return r.runWriters(ctx, jobs)
+}
+
+// goDirFor returns the directory that model code for pkg is written to.
+func (r *run) goDirFor(pkg *packages.Package) string {
+	if r.cfg.GoDir != "" {
+		return r.cfg.GoDir
+	}
+
+	if len(pkg.GoFiles) > 0 {
+		return filepath.Dir(pkg.GoFiles[0])
+	}
+
+	return ""
+}
+
+func logTime(l *logrus.Entry, s string, fn func()) {
+	l = l.WithField("operation", s)
+
+	a := time.Now()
+
+	l = l.WithField("time_start", a)
+
+	l.Debug("starting")
+
+	fn()
+
+	b := time.Now()
+
+	l.WithFields(logrus.Fields{
+		"time_end":   b,
+		"time_total": b.Sub(a),
+	}).Debug("finished")
+}
+
+// findModels makes a model for each struct in a package that is marked with
+// an @apigen comment. Problems with any of the models are returned together
+// as a DiagnosticList.
+func (st *settings) findModels(pkg *packages.Package) ([]*Model, error) {
+	var models []*Model
+	var diagnostics DiagnosticList
+
+	for _, typeName := range pkg.Types.Scope().Names() {
+		obj := pkg.Types.Scope().Lookup(typeName)
+
+		namedType, ok := obj.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+
+		structType, ok := namedType.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+
+		var file *ast.File
+		for _, f := range pkg.Syntax {
+			if obj.Pos() >= f.Pos() && obj.Pos() <= f.End() {
+				file = f
+				break
+			}
+		}
+
+		if file == nil {
+			continue
+		}
+
+		astObject := file.Scope.Lookup(obj.Name())
+		if astObject == nil {
+			continue
+		}
+
+		var directives *modelDirectives
+
+		for _, comment := range file.Comments {
+			if pkg.Fset.Position(comment.End()).Line != pkg.Fset.Position(obj.Pos()).Line-1 {
+				continue
+			}
+
+			d, err := parseModelDirectives(comment.Text())
+			if err != nil {
+				diagnostics = append(diagnostics, Diagnostic{Pos: pkg.Fset.Position(comment.Pos()), Model: typeName, Message: err.Error()})
+				break
+			}
+
+			directives = d
+
+			break
+		}
+
+		if directives == nil {
+			continue
+		}
+
+		model, err := st.makeModel(&modelSource{fset: pkg.Fset, file: file}, directives, typeName, namedType, structType)
+		if err != nil {
+			var d DiagnosticList
+			if !errors.As(err, &d) {
+				d = DiagnosticList{{Pos: pkg.Fset.Position(obj.Pos()), Model: typeName, Message: err.Error()}}
+			}
+
+			diagnostics = append(diagnostics, d...)
+
+			continue
+		}
+
+		models = append(models, model)
+	}
+
+	if len(diagnostics) > 0 {
+		return models, diagnostics
+	}
+
+	return models, nil
+}
+
+func (r *run) checkOutput(l *logrus.Entry, out io.Writer, filename string, generated []byte) error {
+	current, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("checkOutput: couldn't read %s: %w", filename, err)
+	}
+
+	if err == nil && bytes.Equal(current, generated) {
+		l.Debug("output is up to date")
+		return nil
+	}
+
+	l.Warn("output is out of date")
+
+	r.addOutOfDate(filename)
+
+	fromFile, fromLines := filename, difflib.SplitLines(string(current))
+	if err != nil {
+		fromFile, fromLines = "/dev/null", nil
+	}
+
+	if err := difflib.WriteUnifiedDiff(out, difflib.UnifiedDiff{
+		A:        fromLines,
+		B:        difflib.SplitLines(string(generated)),
+		FromFile: fromFile,
+		ToFile:   filename,
+		Context:  3,
+	}); err != nil {
+		return fmt.Errorf("checkOutput: couldn't produce diff for %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// writerJob is a single writer to be run by runWriters. The logs and output
+// of the writer are buffered so that they can be printed in order.
+type writerJob struct {
+	l         *logrus.Entry
+	w         writer
+	c         *cache
+	model     string
+	generator string
+	logs      bytes.Buffer
+	output    bytes.Buffer
+	content   []byte
+	err       error
+	done      chan struct{}
+
+	// afterModels is set for writers that run once the per-model outputs
+	// have been staged. goimports only sees what's on disk, so if any of
+	// those outputs are new, it may resolve the imports of these writers
+	// differently once they've been committed, and their cached output
+	// can't be trusted.
+	afterModels bool
+}
+
+// runWriters executes writers on a pool of up to Config.Jobs workers. Logs
+// and output are flushed in the order the jobs were given as each one
+// finishes, followed by any errors, so that none of it depends on scheduling.
+func (r *run) runWriters(ctx context.Context, jobs []*writerJob) error {
+	queue := make(chan *writerJob)
+
+	go func() {
+		for _, j := range jobs {
+			queue <- j
+		}
+		close(queue)
+	}()
+
+	for _, j := range jobs {
+		j.done = make(chan struct{})
+	}
+
+	for i := 0; i < r.cfg.Jobs; i++ {
+		go func() {
+			for j := range queue {
+				if err := ctx.Err(); err != nil {
+					j.err = err
+					close(j.done)
+					continue
+				}
+
+				logger := logrus.New()
+				logger.SetOutput(&j.logs)
+				logger.SetFormatter(r.l.Logger.Formatter)
+				logger.SetLevel(r.l.Logger.GetLevel())
+
+				useCache := !j.afterModels || !r.staged.createsFiles()
+
+				j.content, j.err = r.executeWriter(logrus.NewEntry(logger).WithFields(j.l.Data), &j.output, j.c, j.w, useCache)
+
+				close(j.done)
+			}
+		}()
+	}
+
+	var errs []error
+
+	for _, j := range jobs {
+		<-j.done
+
+		r.l.Logger.Out.Write(j.logs.Bytes())
+		r.cfg.Diffs.Write(j.output.Bytes())
+
+		if j.err != nil {
+			errs = append(errs, j.err)
+			continue
+		}
+
+		r.m.Lock()
+		r.result.Files = append(r.result.Files, File{
+			Path:      j.w.File(),
+			Model:     j.model,
+			Generator: j.generator,
+			Writer:    j.w.Name(),
+			Language:  j.w.Language(),
+			Content:   j.content,
+		})
+		r.m.Unlock()
+	}
+
+	for _, j := range jobs {
+		if j.err != nil {
+			j.l.WithError(j.err).Error("could not execute writer")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("could not execute %d writer(s): %w", len(errs), errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// newGenerators returns the built-in generators, writing to the given
+// directories.
+func newGenerators(goDir, jsDir, flowDir string, cfg *Config) []generator {
+	return []generator{
+		NewAPIGenerator(goDir, cfg),
+		NewAPIFilterGenerator(goDir, cfg),
+		NewEnumGenerator(goDir, cfg),
+		NewJSGenerator(jsDir, cfg),
+		NewFlowGenerator(flowDir, cfg),
+		NewSchemaGenerator(goDir, cfg),
+		NewSQLGenerator(goDir, cfg),
+	}
+}
+
+// renderWriter produces the complete output of a writer, formatting it unless
+// disableFormatting is set.
+func renderWriter(l *logrus.Entry, w writer, disableFormatting bool) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	var err error
+
+	if w, ok := w.(writerForGo); ok {
+		logTime(l, "execute go header template", func() {
+			err = headerTemplate.Execute(buf, struct {
+				PackageName string
+				Imports     []string
+			}{w.PackageName(), w.Imports()})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not write go header: %w", err)
+		}
+	}
+
+	logTime(l, "generate code", func() {
+		err = w.Write(buf)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not generate code: %w", err)
+	}
+
+	nice := buf.Bytes()
+
+	if w.Language() == "go" && !disableFormatting {
+		logTime(l, "format go code", func() {
+			nice, err = imports.Process(w.File(), nice, nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not format go code: %w", err)
+		}
+	}
+
+	return nice, nil
+}
+
+// executeWriter renders a writer and stages, checks, or discards its output
+// according to the config. The output is returned, unless the writer was
+// skipped because its output was already up to date. Unless useCache is set,
+// the cache is neither consulted nor updated, and the entry for the writer is
+// dropped so that it's generated again by the next run.
+func (r *run) executeWriter(l *logrus.Entry, out io.Writer, c *cache, w writer, useCache bool) ([]byte, error) {
+	filename := w.File()
+
+	l = l.WithField("output", filename)
+
+	input := writerInputHash(w, r.cfg.DisableFormatting, settingsFor(r.cfg).hash)
+
+	// check mode always renders, as the file on disk may have been edited
+	// since it was recorded in the cache
+	if useCache && !r.cfg.Force && !r.cfg.Check && c.fresh(filename, input) {
+		l.Debug("skipping writer as its output is up to date")
+		return nil, nil
+	}
+
+	l.Info("executing writer")
+
+	nice, err := renderWriter(l, w, r.cfg.DisableFormatting)
+	if err != nil {
+		return nil, fmt.Errorf("executeWriter: %w", err)
+	}
+
+	if r.cfg.Check {
+		logTime(l, "check file", func() {
+			err = r.checkOutput(l, out, filename, nice)
+		})
+		return nice, err
+	}
+
+	if !r.cfg.Dry {
+		logTime(l, "stage file", func() {
+			err = r.staged.stage(filename, nice)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("executeWriter: could not write output: %w", err)
+		}
+
+		if useCache {
+			c.record(filename, input, nice)
+		} else {
+			c.forget(filename)
+		}
+	}
+
+	return nice, nil
+}
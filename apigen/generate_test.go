@@ -0,0 +1,543 @@
+package apigen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// freshTree copies the test module, without anything generated, into a
+// temporary directory, and returns the directory along with a config for
+// generating its models.
+func freshTree(t *testing.T) (string, *Config) {
+	t.Helper()
+
+	t.Setenv("GOFLAGS", "-mod=vendor")
+	t.Setenv("GOWORK", "off")
+
+	cfg, _, err := LoadConfig(filepath.Join("testdata", "apigen.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	for _, e := range []string{"go.mod", "vendor", "internal", "models"} {
+		if err := copyTree(filepath.Join("testdata", e), filepath.Join(dir, e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	cfg.Dir = dir
+	cfg.Patterns = []string{"./models"}
+	cfg.Logger = logger
+
+	return dir, cfg
+}
+
+// generateIn runs Generate from within dir, the same way the command is run
+// from the root of a project.
+func generateIn(t *testing.T, dir string, cfg Config) Result {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	res, err := Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+// runGo runs the go command with args in dir, failing the test with its output
+// if it doesn't succeed.
+func runGo(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestGenerateFreshTree runs Generate over a tree that has never had anything
+// generated into it, so none of the packages that the aggregated outputs
+// import exist on disk until the run commits, and builds the result.
+func TestGenerateFreshTree(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+
+	dir, cfg := freshTree(t)
+
+	res := generateIn(t, dir, *cfg)
+
+	if len(res.Files) == 0 {
+		t.Fatal("expected some files to be generated")
+	}
+
+	runGo(t, dir, "build", "./...")
+}
+
+// TestGenerateStaleGeneratedCode removes a field and a user filter that the
+// generated code in the models package refers to, and checks that the models
+// can still be loaded, without allowing source errors, and regenerated.
+func TestGenerateStaleGeneratedCode(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+
+	dir, cfg := freshTree(t)
+
+	generateIn(t, dir, *cfg)
+
+	filename := filepath.Join(dir, "models", "models.go")
+
+	d, err := ioutil.ReadFile(filename)
+	mustNoError(t, err)
+
+	src := string(d)
+	for _, s := range []string{
+		"\tBalance         float64\n",
+		",userFilter",
+		"func CustomerUserFilter(qb *sqlbuilder.SelectStatement, euid *uuid.UUID) *sqlbuilder.SelectStatement {\n\treturn qb\n}\n",
+	} {
+		if !strings.Contains(src, s) {
+			t.Fatalf("expected models.go to contain %q", s)
+		}
+		src = strings.Replace(src, s, "", 1)
+	}
+
+	mustNoError(t, ioutil.WriteFile(filename, []byte(src), 0644))
+
+	res := generateIn(t, dir, *cfg)
+
+	for _, f := range res.Files {
+		if f.Path != filepath.Join(dir, "models", "customer_api.go") {
+			continue
+		}
+
+		for _, s := range []string{"Balance", "CustomerUserFilter"} {
+			if bytes.Contains(f.Content, []byte(s)) {
+				t.Errorf("expected %s not to refer to %s any more", f.Path, s)
+			}
+		}
+	}
+
+	runGo(t, dir, "build", "./...")
+}
+
+// TestModels checks the models printed by -dump_model: that they're
+// restricted by the filters, carry what was inferred for them (e.g. their
+// processes), and that the JSON doesn't change from run to run. The JSON is
+// compared to testdata/dump, which is rewritten with -update.
+func TestModels(t *testing.T) {
+	dir, cfg := freshTree(t)
+
+	var filters WriterFilterList
+	mustNoError(t, filters.Set("Customer,Order"))
+	cfg.Filters = filters
+
+	dump := func() []byte {
+		docs, err := Models(context.Background(), *cfg)
+		mustNoError(t, err)
+
+		var buf bytes.Buffer
+
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+
+		for _, doc := range docs {
+			mustNoError(t, enc.Encode(doc))
+		}
+
+		return buf.Bytes()
+	}
+
+	docs, err := Models(context.Background(), *cfg)
+	mustNoError(t, err)
+
+	if len(docs) != 1 {
+		t.Fatalf("expected one package, got %d", len(docs))
+	}
+
+	assert.Equal(t, ModelsDocumentVersion, docs[0].Version)
+	assert.Equal(t, "models", docs[0].PackageName)
+
+	processes := make(map[string][]string)
+	for _, m := range docs[0].Models {
+		processes[m.Singular] = m.Processes
+	}
+
+	assert.Equal(t, map[string][]string{
+		"Customer": {"Review"},
+		"Order":    {"Fulfilment"},
+	}, processes)
+
+	d := dump()
+
+	assert.Equal(t, string(d), string(dump()), "expected the same JSON every time")
+	assert.NotContains(t, string(d), dir, "expected the JSON not to depend on where the models are")
+
+	goldenDir := filepath.Join("testdata", "dump")
+
+	if *flagUpdate {
+		mustNoError(t, os.MkdirAll(goldenDir, 0755))
+		mustNoError(t, ioutil.WriteFile(filepath.Join(goldenDir, "models.json.golden"), d, 0644))
+	} else {
+		compareGolden(t, goldenDir, map[string][]byte{"models.json": d})
+	}
+}
+
+// TestGenerateCacheAfterNewOutputs checks that the aggregated outputs of a run
+// that creates new files aren't cached, as goimports couldn't see those files
+// when it formatted them, and that they're cached by the run after it.
+func TestGenerateCacheAfterNewOutputs(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+
+	dir, cfg := freshTree(t)
+	goDir := filepath.Join(dir, "models")
+
+	entries := func() map[string]cacheEntry {
+		c, err := loadCache(goDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Entries
+	}
+
+	generateIn(t, dir, *cfg)
+
+	e := entries()
+	if _, ok := e["modelschema/orderschema/orderschema.go"]; !ok {
+		t.Errorf("expected the per-model outputs of the first run to be cached")
+	}
+	if _, ok := e["modelschema/models.go"]; ok {
+		t.Errorf("expected the aggregated outputs of the first run not to be cached")
+	}
+
+	generateIn(t, dir, *cfg)
+
+	if _, ok := entries()["modelschema/models.go"]; !ok {
+		t.Errorf("expected the aggregated outputs of the second run to be cached")
+	}
+
+	check := *cfg
+	check.Check = true
+
+	if res := generateIn(t, dir, check); len(res.OutOfDate) != 0 {
+		t.Errorf("expected everything to be up to date, got %v", res.OutOfDate)
+	}
+}
+
+// TestGenerateGolden checks that Generate produces the same files as the
+// writers do when run directly by TestGolden, and type checks them.
+func TestGenerateGolden(t *testing.T) {
+	dir, cfg := freshTree(t)
+	goDir := filepath.Join(dir, "models")
+
+	cfg.JSDir = filepath.Join(dir, "js")
+	cfg.FlowDir = filepath.Join(dir, "flow")
+
+	res := generateIn(t, dir, *cfg)
+
+	outputs := make(map[string][]byte)
+	for _, f := range res.Files {
+		rel, err := filepath.Rel(dir, f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		outputs[filepath.ToSlash(rel)] = f.Content
+	}
+
+	compareGolden(t, filepath.Join("testdata", "golden"), outputs)
+
+	checkGeneratedGo(t, dir, goDir)
+}
+
+// TestGenerateCheck checks that check mode reports files that have been
+// edited or removed since they were generated, with a diff of each, and
+// leaves them as they are.
+func TestGenerateCheck(t *testing.T) {
+	dir, cfg := freshTree(t)
+	goDir := filepath.Join(dir, "models")
+
+	generateIn(t, dir, *cfg)
+
+	var diffs bytes.Buffer
+
+	check := *cfg
+	check.Check = true
+	check.Diffs = &diffs
+
+	res := generateIn(t, dir, check)
+	assert.Empty(t, res.OutOfDate)
+	assert.Empty(t, diffs.String())
+
+	edited := filepath.Join(goDir, "order_api.go")
+	removed := filepath.Join(goDir, "customer_sql.go")
+
+	d, err := ioutil.ReadFile(edited)
+	mustNoError(t, err)
+	mustNoError(t, ioutil.WriteFile(edited, append(d, "// edited\n"...), 0644))
+	mustNoError(t, os.Remove(removed))
+
+	res = generateIn(t, dir, check)
+
+	sort.Strings(res.OutOfDate)
+	assert.Equal(t, []string{removed, edited}, res.OutOfDate)
+
+	assert.Contains(t, diffs.String(), "--- "+edited+"\n+++ "+edited+"\n")
+	assert.Contains(t, diffs.String(), "\n-// edited\n")
+	assert.Contains(t, diffs.String(), "--- /dev/null\n+++ "+removed+"\n")
+
+	after, err := ioutil.ReadFile(edited)
+	mustNoError(t, err)
+	assert.True(t, strings.HasSuffix(string(after), "// edited\n"), "check mode shouldn't touch files")
+
+	_, err = os.Stat(removed)
+	assert.True(t, os.IsNotExist(err), "check mode shouldn't write files")
+}
+
+// TestGeneratePrune checks that the outputs of a model that's no longer
+// marked with @apigen are reported as stale, kept in the manifests until
+// they're deleted, and deleted along with their directories with -prune.
+func TestGeneratePrune(t *testing.T) {
+	dir, cfg := freshTree(t)
+	goDir := filepath.Join(dir, "models")
+
+	cfg.JSDir = filepath.Join(dir, "js")
+	cfg.FlowDir = filepath.Join(dir, "flow")
+
+	var stale []string
+	for _, f := range generateIn(t, dir, *cfg).Files {
+		if f.Model == "AuditNote" {
+			stale = append(stale, f.Path)
+		}
+	}
+	sort.Strings(stale)
+
+	if len(stale) == 0 {
+		t.Fatal("expected some files to be generated for AuditNote")
+	}
+
+	inManifest := func(filename string) bool {
+		for _, root := range []string{goDir, cfg.JSDir, cfg.FlowDir} {
+			rel, err := filepath.Rel(root, filename)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+
+			m, err := readManifest(root)
+			mustNoError(t, err)
+
+			for _, e := range m.Files {
+				if e == filepath.ToSlash(rel) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	for _, filename := range stale {
+		assert.True(t, inManifest(filename), filename)
+	}
+
+	source := filepath.Join(goDir, "models.go")
+	d, err := ioutil.ReadFile(source)
+	mustNoError(t, err)
+	mustNoError(t, ioutil.WriteFile(source, bytes.Replace(d, []byte("// @apigen table=audit_log_notes"), []byte("// not a model"), 1), 0644))
+
+	// without -prune, stale outputs are left alone, and kept in the
+	// manifests so that they keep being reported
+	generateIn(t, dir, *cfg)
+
+	for _, filename := range stale {
+		_, err := os.Stat(filename)
+		assert.NoError(t, err, filename)
+		assert.True(t, inManifest(filename), filename)
+	}
+
+	check := *cfg
+	check.Check = true
+
+	res := generateIn(t, dir, check)
+	sort.Strings(res.OutOfDate)
+	assert.Equal(t, stale, res.OutOfDate)
+
+	prune := *cfg
+	prune.Prune = true
+
+	generateIn(t, dir, prune)
+
+	for _, filename := range stale {
+		_, err := os.Stat(filename)
+		assert.True(t, os.IsNotExist(err), filename)
+		assert.False(t, inManifest(filename), filename)
+	}
+
+	_, err = os.Stat(filepath.Join(goDir, "modelschema", "auditnoteschema"))
+	assert.True(t, os.IsNotExist(err), "expected the per-model directory to be removed")
+
+	if _, err := exec.LookPath("go"); err == nil {
+		runGo(t, dir, "build", "./...")
+	}
+}
+
+// TestRunWritersOrder checks that the results and logs of writers come out in
+// the order the writers were given, however long each of them takes, and
+// that a failing writer doesn't stop the others from running.
+func TestRunWritersOrder(t *testing.T) {
+	var logs bytes.Buffer
+
+	logger := logrus.New()
+	logger.Out = &logs
+	logger.Formatter = &logrus.TextFormatter{DisableTimestamp: true}
+
+	r, err := newRun(&Config{Jobs: 4, Dry: true, Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	c, err := loadCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jobs []*writerJob
+	for i := 0; i < 8; i++ {
+		i, name := i, fmt.Sprintf("w%d", i)
+
+		w := &basicWriter{
+			name:     name,
+			language: "js",
+			file:     filepath.Join(dir, name+".js"),
+			write: func(wr io.Writer) error {
+				// the later writers finish first
+				time.Sleep(time.Duration(8-i) * 5 * time.Millisecond)
+
+				if i == 5 {
+					return errors.New("broken")
+				}
+
+				_, err := fmt.Fprintf(wr, "%d\n", i)
+				return err
+			},
+		}
+
+		jobs = append(jobs, &writerJob{l: r.l.WithField("writer", name), w: w, c: c, model: name, generator: "test"})
+	}
+
+	err = r.runWriters(context.Background(), jobs)
+	assert.EqualError(t, err, "could not execute 1 writer(s): executeWriter: could not generate code: broken")
+
+	var files []string
+	for _, f := range r.result.Files {
+		files = append(files, f.Writer+":"+string(f.Content))
+	}
+	assert.Equal(t, []string{"w0:0\n", "w1:1\n", "w2:2\n", "w3:3\n", "w4:4\n", "w6:6\n", "w7:7\n"}, files)
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var msg, writer string
+		for _, e := range []string{"could not execute writer", "executing writer"} {
+			if strings.Contains(line, `msg="`+e+`"`) {
+				msg = e
+			}
+		}
+		for _, f := range strings.Fields(line) {
+			if strings.HasPrefix(f, "writer=") {
+				writer = strings.TrimPrefix(f, "writer=")
+			}
+		}
+		lines = append(lines, msg+" "+writer)
+	}
+
+	assert.Equal(t, []string{
+		"executing writer w0",
+		"executing writer w1",
+		"executing writer w2",
+		"executing writer w3",
+		"executing writer w4",
+		"executing writer w5",
+		"executing writer w6",
+		"executing writer w7",
+		"could not execute writer w5",
+	}, lines)
+}
+
+// TestGenerateCache checks that a run straight after another one skips every
+// writer, except those whose output was changed on disk, and that -force runs
+// them all.
+func TestGenerateCache(t *testing.T) {
+	dir, cfg := freshTree(t)
+	goDir := filepath.Join(dir, "models")
+
+	generateIn(t, dir, *cfg)
+
+	// the aggregated outputs aren't cached by a run that creates new files,
+	// so it takes a second run to cache everything
+	generateIn(t, dir, *cfg)
+
+	edited := filepath.Join(goDir, "order_api.go")
+
+	d, err := ioutil.ReadFile(edited)
+	mustNoError(t, err)
+	mustNoError(t, ioutil.WriteFile(edited, append(d, "// edited\n"...), 0644))
+
+	var rendered []string
+	for _, f := range generateIn(t, dir, *cfg).Files {
+		// the Flow manifest writer can't summarise its input, so it's
+		// always run
+		if f.Content != nil && f.Writer != "aggregated/manifest" {
+			rendered = append(rendered, f.Path)
+		}
+	}
+	assert.Equal(t, []string{edited}, rendered)
+
+	after, err := ioutil.ReadFile(edited)
+	mustNoError(t, err)
+	assert.Equal(t, d, after)
+
+	force := *cfg
+	force.Force = true
+
+	for _, f := range generateIn(t, dir, force).Files {
+		assert.NotNil(t, f.Content, f.Path)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package apigen
 
 import (
   "strings"
@@ -6,10 +6,11 @@ import (
 
 type APIGenerator struct {
   dir string
+  cfg *Config
 }
 
-func NewAPIGenerator(dir string) *APIGenerator {
-  return &APIGenerator{dir: dir}
+func NewAPIGenerator(dir string, cfg *Config) *APIGenerator {
+  return &APIGenerator{dir: dir, cfg: cfg}
 }
 
 func (g *APIGenerator) Name() string {
@@ -17,35 +18,48 @@ func (g *APIGenerator) Name() string {
 }
 
 func (g *APIGenerator) Model(model *Model) []writer {
+  vars := map[string]interface{}{"Model": model}
+  st := settingsFor(g.cfg)
+  tpl := st.template("api/individual")
+
   return []writer{
     &basicWriterForGo{
       basicWriter: basicWriter{
         name:     "individual",
         language: "go",
         file:     g.dir + "/" + strings.ToLower(model.Singular) + "_api.go",
-        write:    templateWriter(apiTemplate, map[string]interface{}{"Model": model}),
+        hash:     templateHash(tpl, vars),
+        write:    st.templateWriter(tpl, vars),
       },
-      packageName: "models",
-      imports: []string{
+      packageName: g.cfg.PackageName,
+      imports: withFieldImports([]string{
+        "bytes",
+        "encoding/base64",
         "encoding/csv",
         "encoding/json",
+        "errors",
         "fmt",
+        "regexp",
+        "unicode/utf8",
         "fknsrs.biz/p/civil",
         "fknsrs.biz/p/sqlbuilder",
         "github.com/gorilla/mux",
+        "github.com/lib/pq",
         "github.com/satori/go.uuid",
         "github.com/timewasted/go-accept-headers",
-        "movingdata.com/p/wbi/internal/apifilter",
-        "movingdata.com/p/wbi/internal/changeregistry",
-        "movingdata.com/p/wbi/internal/cookiesession",
-        "movingdata.com/p/wbi/internal/modelutil",
-        "movingdata.com/p/wbi/internal/modelrelations",
-        "movingdata.com/p/wbi/internal/retrydb",
-        "movingdata.com/p/wbi/internal/traceregistry",
-        "movingdata.com/p/wbi/models/modelapifilter/" + strings.ToLower(model.Singular) + "apifilter",
-        "movingdata.com/p/wbi/models/modelenum/" + strings.ToLower(model.Singular) + "enum",
-        "movingdata.com/p/wbi/models/modelschema/" + strings.ToLower(model.Singular) + "schema",
-      },
+        g.cfg.internalImport("apifilter"),
+        g.cfg.internalImport("apitypes"),
+        g.cfg.internalImport("changeregistry"),
+        g.cfg.internalImport("cookiesession"),
+        g.cfg.internalImport("modelutil"),
+        g.cfg.internalImport("modelrelations"),
+        g.cfg.internalImport("retrydb"),
+        g.cfg.internalImport("sqltypes"),
+        g.cfg.internalImport("traceregistry"),
+        g.cfg.modelsImport("modelapifilter", strings.ToLower(model.Singular)+"apifilter"),
+        g.cfg.modelsImport("modelenum", strings.ToLower(model.Singular)+"enum"),
+        g.cfg.modelsImport("modelschema", strings.ToLower(model.Singular)+"schema"),
+      }, model.Fields),
     },
   }
 }
@@ -128,7 +142,7 @@ func {{$Model.Singular}}APIGet(ctx context.Context, db modelutil.RowQueryerConte
 {{range $Field := $Model.Fields}}
   {{if $Field.ScanType}}var x{{$Field.GoName}} {{$Field.ScanType}}{{end}}
 {{- end}}
-  if err := db.QueryRowContext(ctx, qs, qv...).Scan({{range $i, $Field := $Model.Fields}}{{if $Field.ScanType}}&x{{$Field.GoName}}{{else if $Field.Array}}pq.Array(&v.{{$Field.GoName}}){{else}}&v.{{$Field.GoName}}{{end}}, {{end}}); err != nil {
+  if err := db.QueryRowContext(ctx, qs, qv...).Scan({{range $i, $Field := $Model.Fields}}{{if $Field.ScanType}}&x{{$Field.GoName}}{{else if $Field.JSON}}jsonColumn{{$Model.Singular}}{&v.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(&v.{{$Field.GoName}}){{else}}&v.{{$Field.GoName}}{{end}}, {{end}}); err != nil {
     if err == sql.ErrNoRows {
       return nil, nil
     }
@@ -235,7 +249,7 @@ func {{$Model.Singular}}APISearch(ctx context.Context, db modelutil.QueryerConte
 {{range $Field := $Model.Fields}}
     {{if $Field.ScanType}}var x{{$Field.GoName}} {{$Field.ScanType}}{{end}}
 {{- end}}
-    if err := rows.Scan({{range $i, $Field := $Model.Fields}}{{if $Field.ScanType}}&x{{$Field.GoName}}{{else if $Field.Array}}pq.Array(&m.{{$Field.GoName}}){{else}}&m.{{$Field.GoName}}{{end}} /* {{$i}} */, {{end}}); err != nil {
+    if err := rows.Scan({{range $i, $Field := $Model.Fields}}{{if $Field.ScanType}}&x{{$Field.GoName}}{{else if $Field.JSON}}jsonColumn{{$Model.Singular}}{&m.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(&m.{{$Field.GoName}}){{else}}&m.{{$Field.GoName}}{{end}} /* {{$i}} */, {{end}}); err != nil {
       return nil, fmt.Errorf("{{$Model.Singular}}APISearch: couldn't scan result row: %w", err)
     }
 
@@ -296,7 +310,7 @@ func {{$Model.Singular}}APIFind(ctx context.Context, db modelutil.QueryerContext
 {{range $Field := $Model.Fields}}
   {{if $Field.ScanType}}var x{{$Field.GoName}} {{$Field.ScanType}}{{end}}
 {{- end}}
-  if err := db.QueryRowContext(ctx, qs1, qv1...).Scan({{range $i, $Field := $Model.Fields}}{{if $Field.ScanType}}&x{{$Field.GoName}}{{else if $Field.Array}}pq.Array(&m.{{$Field.GoName}}){{else}}&m.{{$Field.GoName}}{{end}} /* {{$i}} */, {{end}}); err != nil {
+  if err := db.QueryRowContext(ctx, qs1, qv1...).Scan({{range $i, $Field := $Model.Fields}}{{if $Field.ScanType}}&x{{$Field.GoName}}{{else if $Field.JSON}}jsonColumn{{$Model.Singular}}{&m.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(&m.{{$Field.GoName}}){{else}}&m.{{$Field.GoName}}{{end}} /* {{$i}} */, {{end}}); err != nil {
     if err == sql.ErrNoRows {
       return nil, nil
     }
@@ -493,7 +507,7 @@ func {{$Model.Singular}}APIHandleSearchCSV(rw http.ResponseWriter, r *http.Reque
   }
 
   for _, e := range v.Records {
-    if err := wr.Write([]string{ {{range $Field := $Model.Fields}}fmt.Sprintf("%v", e.{{$Field.GoName}}),{{end}} }); err != nil {
+    if err := wr.Write([]string{ {{range $Field := $Model.Fields}}{{CSVValue $Field (Join "e." $Field.GoName)}},{{end}} }); err != nil {
       panic(err)
     }
   }
@@ -660,6 +674,45 @@ func (h *{{$Model.Singular}}BeforeSaveHandler) Match(a, b *{{$Model.Singular}})
 }
 {{end}}
 
+{{if $Model.Fields.HasValidation}}
+{{- if $Model.Fields.HasValidationPatterns}}
+var (
+{{- if $Model.Fields.HasEmailValidation}}
+  validate{{$Model.Singular}}Email = regexp.MustCompile({{printf "%q" EmailPattern}})
+{{- end}}
+{{- range $Field := $Model.Fields}}
+{{- if $Field.Validate}}
+{{- if $Field.Validate.Pattern}}
+  validate{{$Model.Singular}}{{$Field.GoName}}Pattern = regexp.MustCompile({{printf "%q" $Field.Validate.Pattern}})
+{{- end}}
+{{- end}}
+{{- end}}
+)
+{{- end}}
+
+// {{$Model.Singular}}Validate checks v against the validate tags of its fields,
+// and returns a *modelutil.ValidationError listing every field that fails them
+func {{$Model.Singular}}Validate(v *{{$Model.Singular}}) error {
+  var errs []modelutil.FieldError
+
+{{range $Field := $Model.Fields}}
+{{- if $Field.Validate}}
+{{- range $Check := $Field.Validate.Checks}}
+  if {{Expand $Check.Go (Join "v." $Field.GoName)}} {
+    errs = append(errs, modelutil.FieldError{Field: "{{$Field.APIName}}", Rule: "{{$Check.Rule}}", Message: {{printf "%q" $Check.Message}}})
+  }
+{{- end}}
+{{- end}}
+{{- end}}
+
+  if len(errs) > 0 {
+    return &modelutil.ValidationError{Model: "{{$Model.Singular}}", Fields: errs}
+  }
+
+  return nil
+}
+{{end}}
+
 {{if $Model.HasAPICreate}}
 func (jsctx *JSContext) {{$Model.Singular}}Create(input {{$Model.Singular}}) *{{$Model.Singular}} {
   v, err := {{$Model.Singular}}APICreate(modelutil.WithPathEntry(jsctx.ctx, fmt.Sprintf("JS#{{$Model.Singular}}Create#{{FormatTemplate $Model.IDField.GoType}}", input.ID)), jsctx.mctx, jsctx.tx, jsctx.uid, jsctx.euid, time.Now(), &input, nil)
@@ -701,6 +754,19 @@ func {{$Model.Singular}}APICreate(ctx context.Context, mctx *modelutil.ModelCont
 
   ic := sqlbuilder.InsertColumns{}
 
+{{range $Field := $Model.Fields}}
+{{- if $Field.Default}}
+  if {{Expand $Field.Default.Zero (Join "input." $Field.GoName)}} {
+{{- if $Field.Default.Pointer}}
+    v := {{$Field.Default.Go}}
+    input.{{$Field.GoName}} = &v
+{{- else}}
+    input.{{$Field.GoName}} = {{$Field.Default.Go}}
+{{- end}}
+  }
+{{- end}}
+{{- end}}
+
 {{if $Model.HasAudit}}
   fields := make(map[string][]interface{})
 {{- end}}
@@ -709,18 +775,24 @@ func {{$Model.Singular}}APICreate(ctx context.Context, mctx *modelutil.ModelCont
 {{- if $Field.Enum}}
 {{- if $Field.Array}}
   for i, v := range input.{{$Field.GoName}} {
-    if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[v] {
+    if {{EnumInvalid (PackageName "enum" $Model.Singular) "v" $Field}} {
       return nil, fmt.Errorf("{{$Model.Singular}}APICreate: value for member %d of field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", i, {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
     }
   }
 {{- else}}
-  if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[input.{{$Field.GoName}}] {
+  if {{EnumInvalid (PackageName "enum" $Model.Singular) (Join "input." $Field.GoName) $Field}} {
     return nil, fmt.Errorf("{{$Model.Singular}}APICreate: value for field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
   }
 {{- end}}
 {{- end}}
 {{end}}
 
+{{- if $Model.Fields.HasValidation}}
+  if err := {{$Model.Singular}}Validate(input); err != nil {
+    return nil, fmt.Errorf("{{$Model.Singular}}APICreate: %w", err)
+  }
+{{- end}}
+
 {{range $Field := $Model.Fields}}
 {{- if not (eq $Field.Sequence "")}}
   if input.{{$Field.GoName}} == 0 {
@@ -904,18 +976,18 @@ func {{$Model.Singular}}APICreate(ctx context.Context, mctx *modelutil.ModelCont
     {{- if $Field.Enum}}
       {{- if $Field.Array}}
         for i, v := range input.{{$Field.GoName}} {
-          if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[v] {
+          if {{EnumInvalid (PackageName "enum" $Model.Singular) "v" $Field}} {
             return nil, fmt.Errorf("{{$Model.Singular}}APICreate: value for member %d of field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", i, {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
           }
         }
       {{- else}}
-        if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[input.{{$Field.GoName}}] {
+        if {{EnumInvalid (PackageName "enum" $Model.Singular) (Join "input." $Field.GoName) $Field}} {
           return nil, fmt.Errorf("{{$Model.Singular}}APICreate: value for field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
         }
       {{- end}}
     {{- end}}
 
-    ic[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.Array}}pq.Array(input.{{$Field.GoName}}){{else}}input.{{$Field.GoName}}{{end}})
+    ic[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.JSON}}jsonColumn{{$Model.Singular}}{input.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(input.{{$Field.GoName}}){{else}}input.{{$Field.GoName}}{{end}})
 
     {{- if $Model.HasAudit}}
       fields["{{$Field.GoName}}"] = []interface{}{input.{{$Field.GoName}}}
@@ -927,7 +999,7 @@ func {{$Model.Singular}}APICreate(ctx context.Context, mctx *modelutil.ModelCont
       var empty{{$Field.GoName}} {{$Field.GoType}}
     {{- end}}
 
-    ic[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.Array}}pq.Array(empty{{$Field.GoName}}){{else}}empty{{$Field.GoName}}{{end}})
+    ic[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.JSON}}jsonColumn{{$Model.Singular}}{empty{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(empty{{$Field.GoName}}){{else}}empty{{$Field.GoName}}{{end}})
   {{- end}}
 {{- end}}
 
@@ -1006,6 +1078,19 @@ func {{$Model.Singular}}APIHandleCreate(rw http.ResponseWriter, r *http.Request,
 
   v, err := {{$Model.Singular}}APICreate(ctx, mctx, tx, uid, euid, time.Now(), &input, options)
   if err != nil {
+{{- if $Model.Fields.HasValidation}}
+    var verr *modelutil.ValidationError
+    if errors.As(err, &verr) {
+      rw.Header().Set("content-type", "application/json")
+      rw.WriteHeader(http.StatusBadRequest)
+
+      if err := json.NewEncoder(rw).Encode(verr); err != nil {
+        panic(err)
+      }
+
+      return
+    }
+{{end}}
     panic(err)
   }
 
@@ -1085,6 +1170,19 @@ func {{$Model.Singular}}APIHandleCreateMultiple(rw http.ResponseWriter, r *http.
   for i := range input.Records {
     v, err := {{$Model.Singular}}APICreate(ctx, mctx, tx, uid, euid, time.Now(), &input.Records[i], options)
     if err != nil {
+{{- if $Model.Fields.HasValidation}}
+      var verr *modelutil.ValidationError
+      if errors.As(err, &verr) {
+        rw.Header().Set("content-type", "application/json")
+        rw.WriteHeader(http.StatusBadRequest)
+
+        if err := json.NewEncoder(rw).Encode(verr); err != nil {
+          panic(err)
+        }
+
+        return
+      }
+{{end}}
       panic(err)
     }
 
@@ -1191,18 +1289,25 @@ func {{$Model.Singular}}APISave(
 {{- if $Field.Enum}}
 {{- if $Field.Array}}
   for i, v := range input.{{$Field.GoName}} {
-    if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[v] {
+    if {{EnumInvalid (PackageName "enum" $Model.Singular) "v" $Field}} {
       return nil, fmt.Errorf("{{$Model.Singular}}APISave: value for member %d of field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", i, {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
     }
   }
 {{- else}}
-  if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[input.{{$Field.GoName}}] {
+  if {{EnumInvalid (PackageName "enum" $Model.Singular) (Join "input." $Field.GoName) $Field}} {
     return nil, fmt.Errorf("{{$Model.Singular}}APISave: value for field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
   }
 {{- end}}
 {{- end}}
 {{- end}}
 
+{{- if $Model.Fields.HasValidation}}
+
+  if err := {{$Model.Singular}}Validate(input); err != nil {
+    return nil, fmt.Errorf("{{$Model.Singular}}APISave: %w", err)
+  }
+{{- end}}
+
   exitActivity := traceregistry.Enter(ctx, &traceregistry.EventModelActivity{
     ID: uuid.Must(uuid.NewV4()),
     Time: time.Now(),
@@ -1333,24 +1438,24 @@ func {{$Model.Singular}}APISave(
 
 {{range $Field := $Model.Fields}}
 {{- if not $Field.IgnoreUpdate}}
-  if {{ (NotEqual (Join "input." $Field.GoName) $Field.GoType (Join "p." $Field.GoName) $Field.GoType) }} {
+  if {{ (NotEqual (Join "input." $Field.GoName) $Field.CompareType (Join "p." $Field.GoName) $Field.CompareType) }} {
     skip = false
 
 {{- if $Field.Enum}}
 {{- if $Field.Array}}
     for i, v := range input.{{$Field.GoName}} {
-      if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[v] {
+      if {{EnumInvalid (PackageName "enum" $Model.Singular) "v" $Field}} {
         return nil, fmt.Errorf("{{$Model.Singular}}APISave: value for member %d of field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", i, {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
       }
     }
 {{- else}}
-    if !{{(PackageName "enum" $Model.Singular)}}.Valid{{$Field.GoName}}[input.{{$Field.GoName}}] {
+    if {{EnumInvalid (PackageName "enum" $Model.Singular) (Join "input." $Field.GoName) $Field}} {
       return nil, fmt.Errorf("{{$Model.Singular}}APISave: value for field \"{{$Field.APIName}}\" was incorrect; expected one of %v but got %q", {{(PackageName "enum" $Model.Singular)}}.Values{{$Field.GoName}}, input.{{$Field.GoName}})
     }
 {{- end}}
 {{- end}}
 
-    uc[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.Array}}pq.Array(input.{{$Field.GoName}}){{else}}input.{{$Field.GoName}}{{end}})
+    uc[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.JSON}}jsonColumn{{$Model.Singular}}{input.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(input.{{$Field.GoName}}){{else}}input.{{$Field.GoName}}{{end}})
 {{- if $Model.HasAudit}}
     changed["{{$Field.GoName}}"] = []interface{}{p.{{$Field.GoName}}, input.{{$Field.GoName}}}
 {{- end}}
@@ -1458,6 +1563,19 @@ func {{$Model.Singular}}APIHandleSave(rw http.ResponseWriter, r *http.Request, m
 
   v, err := {{$Model.Singular}}APISave(ctx, mctx, tx, uid, euid, time.Now(), &input, options)
   if err != nil {
+{{- if $Model.Fields.HasValidation}}
+    var verr *modelutil.ValidationError
+    if errors.As(err, &verr) {
+      rw.Header().Set("content-type", "application/json")
+      rw.WriteHeader(http.StatusBadRequest)
+
+      if err := json.NewEncoder(rw).Encode(verr); err != nil {
+        panic(err)
+      }
+
+      return
+    }
+{{end}}
     panic(err)
   }
 
@@ -1537,6 +1655,19 @@ func {{$Model.Singular}}APIHandleSaveMultiple(rw http.ResponseWriter, r *http.Re
   for i := range input.Records {
     v, err := {{$Model.Singular}}APISave(ctx, mctx, tx, uid, euid, time.Now(), &input.Records[i], options)
     if err != nil {
+{{- if $Model.Fields.HasValidation}}
+      var verr *modelutil.ValidationError
+      if errors.As(err, &verr) {
+        rw.Header().Set("content-type", "application/json")
+        rw.WriteHeader(http.StatusBadRequest)
+
+        if err := json.NewEncoder(rw).Encode(verr); err != nil {
+          panic(err)
+        }
+
+        return
+      }
+{{end}}
       panic(err)
     }
 
@@ -1825,7 +1956,12 @@ func (p *{{$Model.Singular}}Process{{$Process}}) Name() string {
   return "{{$Model.Singular}}.{{$Process}}"
 }
 func (p *{{$Model.Singular}}Process{{$Process}}) GetStatus() string {
+{{- $Status := $Model.Fields.GetByName (printf "%sStatus" $Process)}}
+{{- if eq $Status.GoType "string"}}
   return p.Value.{{$Process}}Status
+{{- else}}
+  return string(p.Value.{{$Process}}Status)
+{{- end}}
 }
 func (p *{{$Model.Singular}}Process{{$Process}}) GetCompletedAt() *time.Time {
   return p.Value.{{$Process}}CompletedAt
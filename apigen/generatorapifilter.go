@@ -1,4 +1,4 @@
-package main
+package apigen
 
 import (
   "strings"
@@ -6,10 +6,11 @@ import (
 
 type APIFilterGenerator struct {
   dir string
+  cfg *Config
 }
 
-func NewAPIFilterGenerator(dir string) *APIFilterGenerator {
-  return &APIFilterGenerator{dir: dir}
+func NewAPIFilterGenerator(dir string, cfg *Config) *APIFilterGenerator {
+  return &APIFilterGenerator{dir: dir, cfg: cfg}
 }
 
 func (g *APIFilterGenerator) Name() string {
@@ -17,31 +18,41 @@ func (g *APIFilterGenerator) Name() string {
 }
 
 func (g *APIFilterGenerator) Model(model *Model) []writer {
+  vars := map[string]interface{}{"Model": model}
+  st := settingsFor(g.cfg)
+  tpl := st.template("apifilter/individual")
+
   return []writer{
     &basicWriterForGo{
       basicWriter: basicWriter{
         name:     "individual",
         language: "go",
         file:     g.dir + "/modelapifilter/" + strings.ToLower(model.Singular) + "apifilter/" + strings.ToLower(model.Singular) + "apifilter.go",
-        write:    templateWriter(apifilterTemplate, map[string]interface{}{"Model": model}),
+        hash:     templateHash(tpl, vars),
+        write:    st.templateWriter(tpl, vars),
       },
       packageName: strings.ToLower(model.Singular) + "apifilter",
-      imports: []string{
+      imports: withFieldImports([]string{
         "strings",
         "time",
         "fknsrs.biz/p/civil",
         "fknsrs.biz/p/sqlbuilder",
         "github.com/satori/go.uuid",
-        "movingdata.com/p/wbi/internal/apifilter",
-        "movingdata.com/p/wbi/models/modelschema/" + strings.ToLower(model.Singular) + "schema",
-      },
+        g.cfg.internalImport("apifilter"),
+        g.cfg.internalImport("modelutil"),
+        g.cfg.modelsImport("modelschema", strings.ToLower(model.Singular)+"schema"),
+      }, model.Fields),
     },
   }
 }
 
 func (g *APIFilterGenerator) Models(models []*Model) []writer {
+  vars := map[string]interface{}{"Models": models, "PackageName": g.cfg.PackageName}
+  st := settingsFor(g.cfg)
+  tpl := st.template("apifilter/aggregated")
+
   imports := []string{
-    "movingdata.com/p/wbi/models",
+    g.cfg.modelsImport(),
   }
 
   for _, model := range models {
@@ -49,7 +60,7 @@ func (g *APIFilterGenerator) Models(models []*Model) []writer {
       continue
     }
 
-    imports = append(imports, "movingdata.com/p/wbi/models/modelapifilter/"+strings.ToLower(model.Singular)+"apifilter")
+    imports = append(imports, g.cfg.modelsImport("modelapifilter", strings.ToLower(model.Singular)+"apifilter"))
   }
 
   return []writer{
@@ -58,7 +69,8 @@ func (g *APIFilterGenerator) Models(models []*Model) []writer {
         name:     "aggregated",
         language: "go",
         file:     g.dir + "/modelapifilter/modelapifilter.go",
-        write:    templateWriter(apifilterFinishTemplate, map[string]interface{}{"Models": models}),
+        hash:     templateHash(tpl, vars),
+        write:    st.templateWriter(tpl, vars),
       },
       packageName: "modelapifilter",
       imports:     imports,
@@ -81,7 +93,11 @@ func RegisterSpecialFilter{{$Filter.GoName}}(fn func({{$Filter.GoType | UnPtr}})
 type FilterParameters struct {
 {{- range $Field := $Model.Fields}}
 {{- range $Filter := $Field.Filters}}
-  {{$Filter.GoName}} {{$Filter.GoType}} "schema:\"{{$Filter.Name}}\" json:\"{{$Filter.Name}},omitempty\" api_filter:\"{{$Field.SQLName}},{{$Filter.Operator}}\""
+{{- if eq $Filter.Operator "sha256"}}
+  {{$Filter.GoName}} {{$Filter.GoType}} "schema:\"{{$Filter.Name}}\" json:\"{{$Filter.Name}},omitempty\""
+{{- else}}
+  {{$Filter.GoName}} {{$Filter.GoType}} "schema:\"{{$Filter.Name}}\" json:\"{{$Filter.Name}},omitempty{{if $Filter.JSONString}},string{{end}}\" api_filter:\"{{$Field.SQLName}},{{$Filter.Operator}}\""
+{{- end}}
 {{- end}}
 {{- end}}
 {{- range $Filter := $Model.SpecialFilters}}
@@ -110,6 +126,15 @@ func (p *FilterParameters) AddFilters(q *sqlbuilder.SelectStatement) *sqlbuilder
 {{- end}}
     a = append(a, specialFilter{{$Filter.GoName}}(*p.{{$Filter.GoName}}))
   }
+{{- end}}
+{{- range $Field := $Model.Fields}}
+{{- range $Filter := $Field.Filters}}
+{{- if eq $Filter.Operator "sha256"}}
+  if p.{{$Filter.GoName}} != nil {
+    a = append(a, sqlbuilder.Eq(sqlbuilder.Func("encode", sqlbuilder.Func("sha256", {{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}), sqlbuilder.Literal("'hex'")), sqlbuilder.Bind(strings.ToLower(*p.{{$Filter.GoName}}))))
+  }
+{{- end}}
+{{- end}}
 {{- end}}
 
   if len(a) > 0 {
@@ -188,12 +213,12 @@ func (p *SearchParameters) AddLimits(q *sqlbuilder.SelectStatement) *sqlbuilder.
 var apifilterFinishTemplate = `
 {{$Models := .Models}}
 
-// Please note: this file is generated from the models package
+// Please note: this file is generated from the {{$.PackageName}} package
 
 func init() {
 {{- range $Model := $Models}}
 {{- range $Filter := $Model.SpecialFilters}}
-  {{ PackageName "apifilter" $Model.Singular }}.RegisterSpecialFilter{{$Filter.GoName}}(models.{{$Model.Singular}}SpecialFilter{{$Filter.GoName}})
+  {{ PackageName "apifilter" $Model.Singular }}.RegisterSpecialFilter{{$Filter.GoName}}({{$.PackageName}}.{{$Model.Singular}}SpecialFilter{{$Filter.GoName}})
 {{- end}}
 {{- end}}
 }
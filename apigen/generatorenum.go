@@ -0,0 +1,181 @@
+package apigen
+
+import (
+	"strings"
+)
+
+type EnumGenerator struct {
+	dir string
+	cfg *Config
+}
+
+func NewEnumGenerator(dir string, cfg *Config) *EnumGenerator {
+	return &EnumGenerator{dir: dir, cfg: cfg}
+}
+
+func (g *EnumGenerator) Name() string {
+	return "enum"
+}
+
+func (g *EnumGenerator) Model(model *Model) []writer {
+	vars := map[string]interface{}{"Model": model}
+	st := settingsFor(g.cfg)
+	tpl := st.template("enum/individual")
+
+	var imports []string
+	if model.Fields.HasTypedEnums() {
+		imports = []string{"database/sql/driver", "fmt"}
+	}
+
+	return []writer{
+		&basicWriterForGo{
+			basicWriter: basicWriter{
+				name:     "individual",
+				language: "go",
+				file:     g.dir + "/modelenum/" + strings.ToLower(model.Singular) + "enum/" + strings.ToLower(model.Singular) + "enum.go",
+				hash:     templateHash(tpl, vars),
+				write:    st.templateWriter(tpl, vars),
+			},
+			packageName: strings.ToLower(model.Singular) + "enum",
+			imports:     imports,
+		},
+	}
+}
+
+var enumTemplate = `
+{{$Model := .Model}}
+
+// Please note: this file is generated from {{$Model.Singular | LC}}.go
+
+{{- range $Field := $Model.Fields}}
+{{- if $Field.Enum}}
+{{- if $Field.TypedEnum}}
+{{- $Optional := not (and $Field.Validate $Field.Validate.Required)}}
+
+// {{$Field.GoName}} is one of the values of {{$Model.Singular}}.{{$Field.GoName}}.
+{{- if $Optional}} The field is
+// optional, so the zero value can be stored and encoded as well.
+{{- end}}
+type {{$Field.GoName}} string
+
+const (
+{{- range $Enum := $Field.Enum}}
+	{{$Field.GoName}}{{$Enum.GoName}} {{$Field.GoName}} = "{{$Enum.Value}}"
+{{- end}}
+)
+
+var (
+	Valid{{$Field.GoName}} = map[string]bool{
+{{- range $Enum := $Field.Enum}}
+		string({{$Field.GoName}}{{$Enum.GoName}}): true,
+{{- end}}
+	}
+	Values{{$Field.GoName}} = []string{
+{{- range $Enum := $Field.Enum}}
+		string({{$Field.GoName}}{{$Enum.GoName}}),
+{{- end}}
+	}
+	Labels{{$Field.GoName}} = map[string]string{
+{{- range $Enum := $Field.Enum}}
+		string({{$Field.GoName}}{{$Enum.GoName}}): "{{$Enum.Label}}",
+{{- end}}
+	}
+)
+
+// All{{$Field.GoName}} returns the values of {{$Field.GoName}}, in the order they're declared.
+func All{{$Field.GoName}}() []{{$Field.GoName}} {
+	return []{{$Field.GoName}}{
+{{- range $Enum := $Field.Enum}}
+		{{$Field.GoName}}{{$Enum.GoName}},
+{{- end}}
+	}
+}
+
+func (v {{$Field.GoName}}) String() string {
+	return string(v)
+}
+
+// Label returns the label of v, or an empty string if it isn't valid.
+func (v {{$Field.GoName}}) Label() string {
+	return Labels{{$Field.GoName}}[string(v)]
+}
+
+func (v {{$Field.GoName}}) IsValid() bool {
+	return Valid{{$Field.GoName}}[string(v)]
+}
+
+func (v {{$Field.GoName}}) MarshalText() ([]byte, error) {
+	if !v.IsValid(){{if $Optional}} && v != ""{{end}} {
+		return nil, fmt.Errorf("{{$Field.GoName}}.MarshalText: expected one of %v but got %q", Values{{$Field.GoName}}, string(v))
+	}
+
+	return []byte(v), nil
+}
+
+func (v *{{$Field.GoName}}) UnmarshalText(b []byte) error {
+	if !Valid{{$Field.GoName}}[string(b)]{{if $Optional}} && len(b) > 0{{end}} {
+		return fmt.Errorf("{{$Field.GoName}}.UnmarshalText: expected one of %v but got %q", Values{{$Field.GoName}}, string(b))
+	}
+
+	*v = {{$Field.GoName}}(b)
+
+	return nil
+}
+
+func (v *{{$Field.GoName}}) Scan(src interface{}) error {
+	var s string
+
+	switch src := src.(type) {
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	case nil:
+	default:
+		return fmt.Errorf("{{$Field.GoName}}.Scan: can't scan %T", src)
+	}
+
+	if !Valid{{$Field.GoName}}[s]{{if $Optional}} && s != ""{{end}} {
+		return fmt.Errorf("{{$Field.GoName}}.Scan: expected one of %v but got %q", Values{{$Field.GoName}}, s)
+	}
+
+	*v = {{$Field.GoName}}(s)
+
+	return nil
+}
+
+func (v {{$Field.GoName}}) Value() (driver.Value, error) {
+	if !v.IsValid(){{if $Optional}} && v != ""{{end}} {
+		return nil, fmt.Errorf("{{$Field.GoName}}.Value: expected one of %v but got %q", Values{{$Field.GoName}}, string(v))
+	}
+
+	return string(v), nil
+}
+{{- else}}
+const (
+{{- range $Enum := $Field.Enum}}
+	{{$Field.GoName}}{{$Enum.GoName}} = "{{$Enum.Value}}"
+{{- end}}
+)
+
+var (
+	Valid{{$Field.GoName}} = map[string]bool{
+{{- range $Enum := $Field.Enum}}
+		{{$Field.GoName}}{{$Enum.GoName}}: true,
+{{- end}}
+	}
+	Values{{$Field.GoName}} = []string{
+{{- range $Enum := $Field.Enum}}
+		{{$Field.GoName}}{{$Enum.GoName}},
+{{- end}}
+	}
+	Labels{{$Field.GoName}} = map[string]string{
+{{- range $Enum := $Field.Enum}}
+		{{$Field.GoName}}{{$Enum.GoName}}: "{{$Enum.Label}}",
+{{- end}}
+	}
+)
+{{- end}}
+{{- end}}
+{{- end}}
+`
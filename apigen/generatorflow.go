@@ -1,4 +1,4 @@
-package main
+package apigen
 
 import (
   "encoding/json"
@@ -8,10 +8,11 @@ import (
 
 type FlowGenerator struct {
   dir string
+  cfg *Config
 }
 
-func NewFlowGenerator(dir string) *FlowGenerator {
-  return &FlowGenerator{dir: dir}
+func NewFlowGenerator(dir string, cfg *Config) *FlowGenerator {
+  return &FlowGenerator{dir: dir, cfg: cfg}
 }
 
 func (g *FlowGenerator) Name() string {
@@ -19,23 +20,33 @@ func (g *FlowGenerator) Name() string {
 }
 
 func (g *FlowGenerator) Model(model *Model) []writer {
+  vars := map[string]interface{}{"Model": model}
+  st := settingsFor(g.cfg)
+  tpl := st.template("flow/individual")
+
   return []writer{
     &basicWriter{
       name:     "individual",
       language: "flow",
       file:     g.dir + "/global_db_model_" + strings.ToLower(model.Singular) + ".js",
-      write:    templateWriter(flowTemplate, map[string]interface{}{"Model": model}),
+      hash:     templateHash(tpl, vars),
+      write:    st.templateWriter(tpl, vars),
     },
   }
 }
 
 func (g *FlowGenerator) Models(models []*Model) []writer {
+  vars := map[string]interface{}{"Models": models}
+  st := settingsFor(g.cfg)
+  tpl := st.template("flow/aggregated/flow")
+
   return []writer{
     &basicWriter{
       name:     "aggregated/flow",
       language: "flow",
       file:     g.dir + "/global_db.js",
-      write:    templateWriter(flowFinishTemplate, map[string]interface{}{"Models": models}),
+      hash:     templateHash(tpl, vars),
+      write:    st.templateWriter(tpl, vars),
     },
     &basicWriter{
       name:     "aggregated/manifest",
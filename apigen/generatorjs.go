@@ -1,11 +1,12 @@
-package main
+package apigen
 
 type JSGenerator struct {
   dir string
+  cfg *Config
 }
 
-func NewJSGenerator(dir string) *JSGenerator {
-  return &JSGenerator{dir: dir}
+func NewJSGenerator(dir string, cfg *Config) *JSGenerator {
+  return &JSGenerator{dir: dir, cfg: cfg}
 }
 
 func (g *JSGenerator) Name() string {
@@ -13,12 +14,17 @@ func (g *JSGenerator) Name() string {
 }
 
 func (g *JSGenerator) Model(model *Model) []writer {
+  vars := map[string]interface{}{"Model": model}
+  st := settingsFor(g.cfg)
+  tpl := st.template("js/individual")
+
   return []writer{
     &basicWriter{
       name:     "individual",
       language: "js",
       file:     g.dir + "/ducks/" + model.LowerPlural + ".js",
-      write:    templateWriter(jsTemplate, map[string]interface{}{"Model": model}),
+      hash:     templateHash(tpl, vars),
+      write:    st.templateWriter(tpl, vars),
     },
   }
 }
@@ -79,6 +85,42 @@ export const {{$Model.LowerPlural}}Labels{{$Field.GoName}}: { [key: {{$Model.Sin
 {{- end}}
 {{- end}}
 
+{{if $Model.Fields.HasValidation}}
+/** {{$Model.Singular}}ValidationError describes a field that breaks one of its rules */
+export type {{$Model.Singular}}ValidationError = {|
+  field: string,
+  rule: string,
+  message: string,
+|};
+{{range $Field := $Model.Fields}}
+{{- if $Field.Validate}}
+
+/** {{$Model.LowerPlural}}Validate{{$Field.GoName}} checks a value for {{$Field.APIName}} against its rules */
+export function {{$Model.LowerPlural}}Validate{{$Field.GoName}}(value: {{$Field.JSType}} | void): $ReadOnlyArray<{{$Model.Singular}}ValidationError> {
+  const errors = [];
+{{range $Check := $Field.Validate.Checks}}
+  if ({{Expand $Check.JS "value"}}) {
+    errors.push({ field: '{{$Field.APIName}}', rule: '{{$Check.Rule}}', message: {{JSString $Check.Message}} });
+  }
+{{- end}}
+
+  return errors;
+}
+{{- end}}
+{{- end}}
+
+/** {{$Model.LowerPlural}}Validate checks every field of input that has rules */
+export function {{$Model.LowerPlural}}Validate(input: $Shape<{{$Model.Singular}}>): $ReadOnlyArray<{{$Model.Singular}}ValidationError> {
+  return [
+{{- range $Field := $Model.Fields}}
+{{- if $Field.Validate}}
+    ...{{$Model.LowerPlural}}Validate{{$Field.GoName}}(input.{{$Field.APIName}}),
+{{- end}}
+{{- end}}
+  ];
+}
+{{end}}
+
 const defaultPageSize = 10;
 
 /** {{$Model.Singular}} is a complete {{$Model.Singular}} object */
@@ -94,7 +136,7 @@ export type {{$Model.Singular}}CreateInput = {|
   id: {{$Model.IDField.JSType}},
 {{- range $Field := $Model.Fields}}
 {{- if not $Field.IgnoreCreate }}
-  {{$Field.APIName}}{{if $Field.OmitEmpty}}?{{end}}: {{$Field.JSType}},
+  {{$Field.APIName}}{{if or $Field.OmitEmpty $Field.Default}}?{{end}}: {{$Field.JSType}},
 {{- end}}
 {{- end}}
 |};
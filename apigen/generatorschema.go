@@ -1,4 +1,4 @@
-package main
+package apigen
 
 import (
 	"strings"
@@ -6,10 +6,11 @@ import (
 
 type SchemaGenerator struct {
 	dir string
+	cfg *Config
 }
 
-func NewSchemaGenerator(dir string) *SchemaGenerator {
-	return &SchemaGenerator{dir: dir}
+func NewSchemaGenerator(dir string, cfg *Config) *SchemaGenerator {
+	return &SchemaGenerator{dir: dir, cfg: cfg}
 }
 
 func (g *SchemaGenerator) Name() string {
@@ -17,17 +18,22 @@ func (g *SchemaGenerator) Name() string {
 }
 
 func (g *SchemaGenerator) Model(model *Model) []writer {
+	vars := map[string]interface{}{"Model": model}
+	st := settingsFor(g.cfg)
+	tpl := st.template("schema/individual")
+
 	return []writer{
 		&basicWriterForGo{
 			basicWriter: basicWriter{
 				name:     "individual",
 				language: "go",
 				file:     g.dir + "/modelschema/" + strings.ToLower(model.Singular) + "schema/" + strings.ToLower(model.Singular) + "schema.go",
-				write:    templateWriter(schemaTemplate, map[string]interface{}{"Model": model}),
+				hash:     templateHash(tpl, vars),
+				write:    st.templateWriter(tpl, vars),
 			},
 			packageName: strings.ToLower(model.Singular) + "schema",
 			imports: []string{
-				"movingdata.com/p/wbi/internal/apitypes",
+				g.cfg.internalImport("apitypes"),
 				"fknsrs.biz/p/sqlbuilder",
 			},
 		},
@@ -35,19 +41,31 @@ func (g *SchemaGenerator) Model(model *Model) []writer {
 }
 
 func (g *SchemaGenerator) Models(models []*Model) []writer {
+	vars := map[string]interface{}{"Models": models}
+	st := settingsFor(g.cfg)
+	tpl := st.template("schema/aggregated")
+
+	// the per-model schema packages are staged alongside this file, so
+	// goimports can't find them on disk and they have to be named here
+	imports := []string{
+		g.cfg.internalImport("apitypes"),
+		"fknsrs.biz/p/sqlbuilder",
+	}
+	for _, model := range models {
+		imports = append(imports, g.cfg.modelsImport("modelschema", strings.ToLower(model.Singular)+"schema"))
+	}
+
 	return []writer{
 		&basicWriterForGo{
 			basicWriter: basicWriter{
 				name:     "aggregated",
 				language: "go",
 				file:     g.dir + "/modelschema/models.go",
-				write:    templateWriter(schemaFinishTemplate, map[string]interface{}{"Models": models}),
+				hash:     templateHash(tpl, vars),
+				write:    st.templateWriter(tpl, vars),
 			},
 			packageName: "modelschema",
-			imports: []string{
-				"movingdata.com/p/wbi/internal/apitypes",
-				"fknsrs.biz/p/sqlbuilder",
-			},
+			imports:     imports,
 		},
 	}
 }
@@ -91,6 +109,9 @@ var (
 		APIType: "{{$Field.JSType}}",
 		Array: {{if $Field.Array}}true{{else}}false{{end}},
 		NotNull: {{if $Field.IsNull}}false{{else}}true{{end}},
+{{- if $Field.Default}}
+		Default: &apitypes.Default{Kind: "{{$Field.Default.Kind}}", Value: {{printf "%q" $Field.Default.Value}}},
+{{- end}}
 {{- if $Field.Enum}}
 		Enum: []apitypes.Enum{
 {{- range $Enum := $Field.Enum}}
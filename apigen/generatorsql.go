@@ -1,4 +1,4 @@
-package main
+package apigen
 
 import (
 	"strings"
@@ -6,10 +6,11 @@ import (
 
 type SQLGenerator struct {
 	dir string
+	cfg *Config
 }
 
-func NewSQLGenerator(dir string) *SQLGenerator {
-	return &SQLGenerator{dir: dir}
+func NewSQLGenerator(dir string, cfg *Config) *SQLGenerator {
+	return &SQLGenerator{dir: dir, cfg: cfg}
 }
 
 func (g *SQLGenerator) Name() string {
@@ -17,24 +18,34 @@ func (g *SQLGenerator) Name() string {
 }
 
 func (g *SQLGenerator) Model(model *Model) []writer {
+	vars := map[string]interface{}{"Model": model}
+	st := settingsFor(g.cfg)
+	tpl := st.template("sql/individual")
+
 	return []writer{
 		&basicWriterForGo{
 			basicWriter: basicWriter{
 				name:     "individual",
 				language: "go",
 				file:     g.dir + "/" + strings.ToLower(model.Singular) + "_sql.go",
-				write:    templateWriter(sqlTemplate, map[string]interface{}{"Model": model}),
+				hash:     templateHash(tpl, vars),
+				write:    st.templateWriter(tpl, vars),
 			},
-			packageName: "models",
-			imports: []string{
+			packageName: g.cfg.PackageName,
+			imports: withFieldImports([]string{
+				"bytes",
 				"context",
 				"database/sql",
+				"database/sql/driver",
+				"encoding/json",
 				"time",
 				"fknsrs.biz/p/sqlbuilder",
+				"github.com/lib/pq",
 				"github.com/satori/go.uuid",
-				"movingdata.com/p/wbi/internal/modelutil",
-				"movingdata.com/p/wbi/models/modelschema/" + strings.ToLower(model.Singular) + "schema",
-			},
+				g.cfg.internalImport("modelutil"),
+				g.cfg.internalImport("sqltypes"),
+				g.cfg.modelsImport("modelschema", strings.ToLower(model.Singular)+"schema"),
+			}, model.Fields),
 		},
 	}
 }
@@ -59,7 +70,7 @@ func {{$Model.Singular}}SQLFindOne(ctx context.Context, db modelutil.RowQueryerC
 	}
 
 	var m {{$Model.Singular}}
-	if err := db.QueryRowContext(ctx, qs, qv...).Scan({{range $i, $Field := $Model.Fields}}{{if $Field.Array}}pq.Array(&m.{{$Field.GoName}}){{else}}&m.{{$Field.GoName}}{{end}}, {{end}}); err != nil {
+	if err := db.QueryRowContext(ctx, qs, qv...).Scan({{range $i, $Field := $Model.Fields}}{{if $Field.JSON}}jsonColumn{{$Model.Singular}}{&m.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(&m.{{$Field.GoName}}){{else}}&m.{{$Field.GoName}}{{end}}, {{end}}); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -153,7 +164,7 @@ func {{$Model.Singular}}SQLCreate(ctx context.Context, db modelutil.ExecerContex
 {{- end}}
 {{- range $Field := $Model.Fields}}
 {{- if not $Field.IgnoreCreate}}
-		{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}: sqlbuilder.Bind({{if $Field.Array}}pq.Array(m.{{$Field.GoName}}){{else}}m.{{$Field.GoName}}{{end}}),
+		{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}: sqlbuilder.Bind({{if $Field.JSON}}jsonColumn{{$Model.Singular}}{m.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(m.{{$Field.GoName}}){{else}}m.{{$Field.GoName}}{{end}}),
 {{- end}}
 {{- end}}
 	})
@@ -210,8 +221,8 @@ func {{$Model.Singular}}SQLSave(ctx context.Context, db interface { modelutil.Ro
 
 {{- range $Field := $Model.Fields}}
 {{- if not $Field.IgnoreUpdate}}
-	if {{ (NotEqual (Join "m." $Field.GoName) $Field.GoType (Join "p." $Field.GoName) $Field.GoType) }} {
-		uc[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.Array}}pq.Array(m.{{$Field.GoName}}){{else}}m.{{$Field.GoName}}{{end}})
+	if {{ (NotEqual (Join "m." $Field.GoName) $Field.CompareType (Join "p." $Field.GoName) $Field.CompareType) }} {
+		uc[{{(PackageName "schema" $Model.Singular)}}.Column{{$Field.GoName}}] = sqlbuilder.Bind({{if $Field.JSON}}jsonColumn{{$Model.Singular}}{m.{{$Field.GoName}}}{{else if $Field.Array}}pq.Array(m.{{$Field.GoName}}){{else}}m.{{$Field.GoName}}{{end}})
 	}
 {{- end}}
 {{- end}}
@@ -230,4 +241,49 @@ func {{$Model.Singular}}SQLSave(ctx context.Context, db interface { modelutil.Ro
 	return nil
 }
 {{end}}
+
+{{if $Model.Fields.HasJSON}}
+// jsonColumn{{$Model.Singular}} converts the {{$Model.Singular}} fields that are stored as jsonb to and from json
+type jsonColumn{{$Model.Singular}} struct {
+	v interface{}
+}
+
+// Value encodes the field as json, or as a null if it's a nil pointer, slice, or map
+func (c jsonColumn{{$Model.Singular}}) Value() (driver.Value, error) {
+	d, err := json.Marshal(c.v)
+	if err != nil {
+		return nil, fmt.Errorf("jsonColumn{{$Model.Singular}}.Value: couldn't encode value: %w", err)
+	}
+
+	if string(d) == "null" {
+		return nil, nil
+	}
+
+	return d, nil
+}
+
+// Scan decodes json from the database into the field, which v points to
+func (c jsonColumn{{$Model.Singular}}) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, c.v)
+	case string:
+		return json.Unmarshal([]byte(src), c.v)
+	default:
+		return fmt.Errorf("jsonColumn{{$Model.Singular}}.Scan: can't decode %T", src)
+	}
+}
+
+// String returns the field encoded as json, for CSV exports
+func (c jsonColumn{{$Model.Singular}}) String() string {
+	d, err := json.Marshal(c.v)
+	if err != nil {
+		return ""
+	}
+
+	return string(d)
+}
+{{end}}
 `
@@ -0,0 +1,385 @@
+package apigen
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+)
+
+var flagUpdate = flag.Bool("update", false, "rewrite the golden files in testdata/golden and testdata/dump")
+
+// TestGolden runs every generator over the models in testdata/models, compares
+// the output to the files in testdata/golden, and type checks the generated Go
+// code along with the hand written parts of the models package.
+func TestGolden(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=vendor")
+	t.Setenv("GOWORK", "off")
+
+	base, _, err := LoadConfig(filepath.Join("testdata", "apigen.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the models are loaded and the output is formatted from within a copy
+	// of the test module, so goimports can resolve the vendored packages the
+	// same way it would when run in a real project, and nothing generated
+	// by an earlier run is left lying around
+	dir := t.TempDir()
+	goDir := filepath.Join(dir, "models")
+
+	for _, e := range []string{"go.mod", "vendor", "internal", "models"} {
+		if err := copyTree(filepath.Join("testdata", e), filepath.Join(dir, e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	l := logrus.NewEntry(logger)
+
+	base.Dir = dir
+	base.Patterns = []string{"./models"}
+	base.Logger = logger
+
+	r, err := newRun(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, modelsByPackage, err := r.load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("expected one package, got %d", len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	models := modelsByPackage[pkg]
+
+	cfg := base.forPackage(pkg.Types.Name(), pkg.Types.Path())
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var writers []writer
+	for _, g := range newGenerators(goDir, filepath.Join(dir, "js"), filepath.Join(dir, "flow"), cfg) {
+		if g, ok := g.(generatorForModel); ok {
+			for _, model := range models {
+				writers = append(writers, g.Model(model)...)
+			}
+		}
+		if g, ok := g.(generatorForModels); ok {
+			writers = append(writers, g.Models(models)...)
+		}
+	}
+
+	outputs := make(map[string][]byte)
+
+	for _, w := range writers {
+		rel, err := filepath.Rel(dir, w.File())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := renderWriter(l, w, false)
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+
+		outputs[filepath.ToSlash(rel)] = d
+
+		if err := os.MkdirAll(filepath.Dir(w.File()), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(w.File(), d, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Chdir(wd); err != nil {
+		t.Fatal(err)
+	}
+
+	goldenDir := filepath.Join("testdata", "golden")
+
+	if *flagUpdate {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			t.Fatal(err)
+		}
+
+		for rel, d := range outputs {
+			filename := filepath.Join(goldenDir, filepath.FromSlash(rel)+".golden")
+			if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filename, d, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	} else {
+		compareGolden(t, goldenDir, outputs)
+	}
+
+	checkGeneratedGo(t, dir, goDir)
+}
+
+func compareGolden(t *testing.T, goldenDir string, outputs map[string][]byte) {
+	seen := make(map[string]bool)
+
+	if err := filepath.Walk(goldenDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(goldenDir, path)
+		if err != nil {
+			return err
+		}
+		rel = strings.TrimSuffix(filepath.ToSlash(rel), ".golden")
+
+		seen[rel] = true
+
+		d, ok := outputs[rel]
+		if !ok {
+			t.Errorf("%s: golden file exists but nothing generated it; run with -update", rel)
+			return nil
+		}
+
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(d, expected) {
+			diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(expected)),
+				B:        difflib.SplitLines(string(d)),
+				FromFile: "golden",
+				ToFile:   "generated",
+				Context:  3,
+			})
+
+			t.Errorf("%s: output differs from golden file; run with -update if this is expected\n%s", rel, diff)
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var missing []string
+	for rel := range outputs {
+		if !seen[rel] {
+			missing = append(missing, rel)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, rel := range missing {
+		t.Errorf("%s: no golden file; run with -update", rel)
+	}
+}
+
+// checkGeneratedGo type checks every package under root, which is the models
+// package with the generated code written into it, within the copy of the
+// test module in dir.
+func checkGeneratedGo(t *testing.T, dir, root string) {
+	modulePath, err := readModulePath(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imp := &goldenImporter{
+		fset:       token.NewFileSet(),
+		dir:        dir,
+		modulePath: modulePath,
+		std:        importer.Default(),
+		pkgs:       make(map[string]*types.Package),
+		failed:     make(map[string]error),
+		checking:   make(map[string]bool),
+	}
+
+	if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.go"))
+		if err != nil || len(matches) == 0 {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if _, err := imp.Import(modulePath + "/" + filepath.ToSlash(rel)); err != nil {
+			imp.errs = append(imp.errs, err)
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range imp.errs {
+		t.Error(err)
+	}
+}
+
+// goldenImporter type checks the packages of the test module from source,
+// along with its vendored dependencies and runtime support packages, which
+// are stubs of the real ones. Anything else that isn't in the standard library
+// fails to import, so everything that the generated code refers to has to be
+// declared by one of the stubs.
+type goldenImporter struct {
+	fset       *token.FileSet
+	dir        string
+	modulePath string
+	std        types.Importer
+	pkgs       map[string]*types.Package
+	failed     map[string]error
+	checking   map[string]bool
+	errs       []error
+}
+
+func (i *goldenImporter) Import(path string) (*types.Package, error) {
+	if p, ok := i.pkgs[path]; ok {
+		return p, nil
+	}
+	if err, ok := i.failed[path]; ok {
+		return nil, err
+	}
+
+	var dir string
+
+	switch {
+	case path == i.modulePath || strings.HasPrefix(path, i.modulePath+"/"):
+		dir = filepath.Join(i.dir, filepath.FromSlash(strings.TrimPrefix(path, i.modulePath)))
+	case strings.Contains(strings.Split(path, "/")[0], "."):
+		dir = filepath.Join(i.dir, "vendor", filepath.FromSlash(path))
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("%s isn't vendored in the test module", path)
+		}
+	default:
+		return i.std.Import(path)
+	}
+
+	if i.checking[path] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+
+	i.checking[path] = true
+	defer delete(i.checking, path)
+
+	p, err := i.check(path, dir)
+	if err != nil {
+		i.failed[path] = err
+		return nil, err
+	}
+
+	i.pkgs[path] = p
+
+	return p, nil
+}
+
+func (i *goldenImporter) check(path, dir string) (*types.Package, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	for _, filename := range matches {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(i.fset, filename, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	cfg := types.Config{
+		Importer: i,
+		Error: func(err error) {
+			i.errs = append(i.errs, err)
+		},
+	}
+
+	// the details of any errors have already been collected by cfg.Error
+	p, err := cfg.Check(path, i.fset, files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s doesn't type check", path)
+	}
+
+	return p, nil
+}
+
+// readModulePath returns the path of the module declared in a go.mod file.
+func readModulePath(filename string) (string, error) {
+	d, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(d), "\n") {
+		if f := strings.Fields(line); len(f) == 2 && f[0] == "module" {
+			return f[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("%s doesn't declare a module", filename)
+}
+
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+
+		d, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(dst, rel), d, 0644)
+	})
+}
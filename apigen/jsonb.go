@@ -0,0 +1,215 @@
+package apigen
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// isJSONB reports whether fields of type t are stored as jsonb, which is the
+// case for structs that apigen doesn't otherwise know about, maps keyed by
+// strings, and pointers to or slices of either.
+func (r typeRegistry) isJSONB(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Pointer:
+		return r.isJSONB(t.Elem())
+	case *types.Slice:
+		return !isByteSlice(t) && r.isJSONB(t.Elem())
+	case *types.Map:
+		b, ok := t.Key().Underlying().(*types.Basic)
+		return ok && b.Kind() == types.String
+	case *types.Named:
+		if _, ok := r[qualifiedTypeName(t.Obj())]; ok {
+			return false
+		}
+
+		_, ok := t.Underlying().(*types.Struct)
+		return ok
+	case *types.Struct:
+		return true
+	default:
+		return false
+	}
+}
+
+// qualifiedTypeName is the name that types are registered under, e.g.
+// uuid.UUID.
+func qualifiedTypeName(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+
+	return obj.Pkg().Name() + "." + obj.Name()
+}
+
+// jsonbTypes describes the JSON that a value of type t is encoded as, as JS
+// and Flow types and a Swagger schema. Structs become exact object types, with
+// the same field names that model fields get.
+func (st *settings) jsonbTypes(t types.Type) (string, string, *SwaggerType, error) {
+	return st.jsonbWalk(t, make(map[*types.Named]bool))
+}
+
+func (st *settings) jsonbWalk(t types.Type, seen map[*types.Named]bool) (string, string, *SwaggerType, error) {
+	switch t := t.(type) {
+	case *types.Pointer:
+		js, flow, swagger, err := st.jsonbWalk(t.Elem(), seen)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		swagger.Nullable = true
+
+		return "?" + js, "?" + flow, swagger, nil
+	case *types.Slice:
+		if isByteSlice(t) {
+			return st.types.jsonbLeaf("[]byte")
+		}
+
+		js, flow, swagger, err := st.jsonbWalk(t.Elem(), seen)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		return "$ReadOnlyArray<" + js + ">", "$ReadOnlyArray<" + flow + ">", &SwaggerType{Type: "array", Items: swagger}, nil
+	case *types.Map:
+		if b, ok := t.Key().Underlying().(*types.Basic); !ok || b.Kind() != types.String {
+			return "", "", nil, fmt.Errorf("maps have to be keyed by strings to be stored as json, not %s", t.Key())
+		}
+
+		js, flow, swagger, err := st.jsonbWalk(t.Elem(), seen)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		return "{ [key: string]: " + js + " }", "{ [key: string]: " + flow + " }", &SwaggerType{Type: "object", AdditionalProperties: swagger}, nil
+	case *types.Basic:
+		return st.types.jsonbLeaf(t.Name())
+	case *types.Alias:
+		return st.jsonbWalk(types.Unalias(t), seen)
+	case *types.Interface:
+		if t.Empty() {
+			return "any", "any", &SwaggerType{Type: "any"}, nil
+		}
+	case *types.Named:
+		name := qualifiedTypeName(t.Obj())
+		if _, ok := st.types[name]; ok {
+			return st.types.jsonbLeaf(name)
+		}
+
+		switch u := t.Underlying().(type) {
+		case *types.Basic:
+			return st.types.jsonbLeaf(u.Name())
+		case *types.Struct:
+			if hasMethod(t, "MarshalJSON") || hasMethod(t, "MarshalText") {
+				return "", "", nil, fmt.Errorf("%s has its own json encoding, so it has to be declared in the config's types", name)
+			}
+			if seen[t] {
+				return "", "", nil, fmt.Errorf("%s refers to itself, so it can't be described as json", name)
+			}
+
+			seen[t] = true
+			defer delete(seen, t)
+
+			return st.jsonbObject(u, seen)
+		default:
+			return st.jsonbWalk(u, seen)
+		}
+	case *types.Struct:
+		return st.jsonbObject(t, seen)
+	}
+
+	return "", "", nil, fmt.Errorf("can't describe %s as json", t)
+}
+
+// leafTypeName returns the name that t, or the type it points to, is
+// registered under, if it's a basic or named type.
+func (r typeRegistry) leafTypeName(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Name()
+	case *types.Named:
+		name := qualifiedTypeName(t.Obj())
+		if _, ok := r[name]; ok {
+			return name
+		}
+		if b, ok := t.Underlying().(*types.Basic); ok {
+			return b.Name()
+		}
+
+		return name
+	}
+
+	return ""
+}
+
+// jsonbLeaf describes values of a registered type.
+func (r typeRegistry) jsonbLeaf(name string) (string, string, *SwaggerType, error) {
+	m, ok := r[name]
+	if !ok || m.JS == "" || m.Flow == "" || m.Swagger == nil {
+		return "", "", nil, fmt.Errorf("%s isn't a type apigen knows about; declare it in the config's types", name)
+	}
+
+	swagger := *m.Swagger
+
+	return m.JS, m.Flow, &swagger, nil
+}
+
+// jsonbObject describes a struct, including the fields promoted from structs
+// embedded in it.
+func (st *settings) jsonbObject(t *types.Struct, seen map[*types.Named]bool) (string, string, *SwaggerType, error) {
+	var problem error
+	fields := flattenFields(t, func(f *types.Var, tag bool, format string, args ...interface{}) {
+		if problem == nil {
+			problem = fmt.Errorf("%s: %s", f.Name(), fmt.Sprintf(format, args...))
+		}
+	})
+	if problem != nil {
+		return "", "", nil, problem
+	}
+
+	var js, flow []string
+	swagger := &SwaggerType{Type: "object", Properties: make(map[string]*SwaggerType)}
+
+	for _, sf := range fields {
+		f := sf.t.Field(sf.i)
+		if !f.Exported() {
+			continue
+		}
+
+		jsonName, jsonOptions := getAndParseTagIndex(sf.t, sf.i, "json")
+		if jsonName == "-" {
+			continue
+		}
+
+		name := jsonName
+		if name == "" {
+			name = st.upperCamelLowerCamel.String(f.Name())
+		}
+
+		if name := st.types.leafTypeName(f.Type()); st.types[name].JSONString {
+			if _, ok := jsonOptions["string"]; !ok {
+				return "", "", nil, fmt.Errorf("%s: %s is a string in JS, so the field needs the json string option", f.Name(), name)
+			}
+		}
+
+		fieldJS, fieldFlow, fieldSwagger, err := st.jsonbWalk(f.Type(), seen)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+
+		key := name
+		if _, ok := jsonOptions["omitempty"]; ok {
+			key += "?"
+		}
+
+		js = append(js, key+": "+fieldJS)
+		flow = append(flow, key+": "+fieldFlow)
+		swagger.Properties[name] = fieldSwagger
+	}
+
+	return "{| " + strings.Join(js, ", ") + " |}", "{| " + strings.Join(flow, ", ") + " |}", swagger, nil
+}
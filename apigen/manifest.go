@@ -0,0 +1,367 @@
+package apigen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	manifestFile    = ".apigen-manifest.json"
+	manifestVersion = 1
+)
+
+// manifest records the files generated into a single output root, so that
+// outputs that are no longer produced by any writer can be found later.
+type manifest struct {
+	Version int      `json:"version"`
+	Files   []string `json:"files"`
+}
+
+func readManifest(root string) (*manifest, error) {
+	d, err := ioutil.ReadFile(filepath.Join(root, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("readManifest: couldn't read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(d, &m); err != nil {
+		return nil, fmt.Errorf("readManifest: couldn't parse manifest: %w", err)
+	}
+
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("readManifest: unsupported manifest version %d", m.Version)
+	}
+
+	return &m, nil
+}
+
+func writeManifest(root string, m *manifest) error {
+	d, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("writeManifest: couldn't encode manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("writeManifest: couldn't prepare directory: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(root, manifestFile), append(d, '\n'), 0644); err != nil {
+		return fmt.Errorf("writeManifest: couldn't write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// manifestRoot returns the output root that contains filename, preferring the
+// most specific one if roots are nested.
+func manifestRoot(roots []string, filename string) (string, string, bool) {
+	var root, rel string
+
+	for _, r := range roots {
+		p, err := filepath.Rel(r, filename)
+		if err != nil || p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		if root == "" || len(r) > len(root) {
+			root, rel = r, filepath.ToSlash(p)
+		}
+	}
+
+	return root, rel, root != ""
+}
+
+// syncManifests compares the files produced in this run against the manifest
+// recorded for each output root during the previous run, then reports (or,
+// with -prune, deletes) any file that is no longer produced and records the
+// new manifest.
+func (r *run) syncManifests(l *logrus.Entry, roots []string, produced []string) error {
+	for i, r := range roots {
+		roots[i] = filepath.Clean(r)
+	}
+
+	current := make(map[string]map[string]bool)
+	for _, r := range roots {
+		current[r] = make(map[string]bool)
+	}
+
+	for _, filename := range produced {
+		root, rel, ok := manifestRoot(roots, filepath.Clean(filename))
+		if !ok {
+			l.WithField("output", filename).Warn("output is not inside any output root; it won't be tracked")
+			continue
+		}
+
+		current[root][rel] = true
+	}
+
+	for _, root := range roots {
+		files, ok := current[root]
+		if !ok {
+			continue
+		}
+		delete(current, root)
+
+		l := l.WithField("root", root)
+
+		previous, err := readManifest(root)
+		if err != nil {
+			return fmt.Errorf("syncManifests: %s: %w", root, err)
+		}
+
+		if previous != nil {
+			for _, rel := range previous.Files {
+				if files[rel] {
+					continue
+				}
+
+				filename := filepath.Join(root, filepath.FromSlash(rel))
+
+				if _, err := os.Stat(filename); os.IsNotExist(err) {
+					continue
+				}
+
+				l := l.WithField("output", filename)
+
+				switch {
+				case r.cfg.Check:
+					l.Warn("output is stale")
+					r.addOutOfDate(filename)
+				case !r.cfg.Prune:
+					l.Warn("output is stale; run with -prune to delete it")
+				case r.cfg.Dry:
+					l.Info("would delete stale output")
+				default:
+					l.Info("deleting stale output")
+
+					if err := os.Remove(filename); err != nil {
+						return fmt.Errorf("syncManifests: couldn't delete %s: %w", filename, err)
+					}
+
+					// clean up the per-model directories used by some writers,
+					// e.g. modelenum/<x>enum; this fails harmlessly if the
+					// directory isn't empty
+					if dir := filepath.Dir(filename); dir != root {
+						_ = os.Remove(dir)
+					}
+				}
+			}
+		}
+
+		if r.cfg.Dry || r.cfg.Check {
+			continue
+		}
+
+		m := manifest{Version: manifestVersion, Files: []string{}}
+		for rel := range files {
+			m.Files = append(m.Files, rel)
+		}
+		sort.Strings(m.Files)
+
+		// keep stale files in the manifest until they're actually deleted, so
+		// they keep being reported on subsequent runs
+		if previous != nil && !r.cfg.Prune {
+			for _, rel := range previous.Files {
+				if files[rel] {
+					continue
+				}
+
+				if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel))); err == nil {
+					m.Files = append(m.Files, rel)
+				}
+			}
+			sort.Strings(m.Files)
+		}
+
+		if err := writeManifest(root, &m); err != nil {
+			return fmt.Errorf("syncManifests: %s: %w", root, err)
+		}
+	}
+
+	return nil
+}
+
+// generatedOverlay maps the generated files recorded in the manifests of the
+// packages matching patterns to empty source files. Type checking with this as
+// an overlay means stale generated code (e.g. code referring to a field that
+// has since been removed) can't cause errors in the models package.
+//
+// It also stands in for enum packages that haven't been generated yet, so
+// that models with typed enum fields can be loaded on the first run.
+func (r *run) generatedOverlay(ctx context.Context) (map[string][]byte, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Dir:     r.cfg.Dir,
+		Mode:    packages.NeedName | packages.NeedFiles,
+	}, r.cfg.Patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("generatedOverlay: couldn't list packages: %w", err)
+	}
+
+	overlay := make(map[string][]byte)
+
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+
+		root := r.goDirFor(pkg)
+
+		m, err := readManifest(root)
+		if err != nil {
+			return nil, fmt.Errorf("generatedOverlay: %s: %w", root, err)
+		}
+
+		generated := make(map[string]bool)
+		if m != nil {
+			for _, rel := range m.Files {
+				generated[filepath.Join(root, filepath.FromSlash(rel))] = true
+			}
+		}
+
+		var sources []string
+		for _, filename := range pkg.GoFiles {
+			if generated[filepath.Clean(filename)] {
+				overlay[filename] = []byte("package " + pkg.Name + "\n")
+			} else {
+				sources = append(sources, filename)
+			}
+		}
+
+		modelsImportPath := r.cfg.ModelsImportPath
+		if modelsImportPath == "" {
+			modelsImportPath = pkg.PkgPath
+		}
+
+		enums, err := missingEnumPackages(sources, root, modelsImportPath)
+		if err != nil {
+			return nil, fmt.Errorf("generatedOverlay: %s: %w", pkg.PkgPath, err)
+		}
+
+		for filename, d := range enums {
+			overlay[filename] = d
+		}
+	}
+
+	return overlay, nil
+}
+
+// missingEnumPackages finds the enum packages that the given source files of
+// a models package import but that haven't been generated in root yet, and
+// returns source files for them that declare the types the fields of the
+// models are declared with. Those are always strings, and the rest of the
+// enum package isn't needed to find the models.
+func missingEnumPackages(sources []string, root, modelsImportPath string) (map[string][]byte, error) {
+	prefix := modelsImportPath + "/modelenum/"
+
+	// typeNames holds the types used from each enum package, keyed by its
+	// name
+	typeNames := make(map[string]map[string]bool)
+
+	fset := token.NewFileSet()
+
+	for _, filename := range sources {
+		f, err := parser.ParseFile(fset, filename, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil, fmt.Errorf("missingEnumPackages: %w", err)
+		}
+
+		// local names of the enum packages, mapped to their package names
+		local := make(map[string]string)
+		for _, spec := range f.Imports {
+			p, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || !strings.HasPrefix(p, prefix) || strings.Contains(strings.TrimPrefix(p, prefix), "/") {
+				continue
+			}
+
+			name := path.Base(p)
+			if _, err := os.Stat(filepath.Join(root, "modelenum", name, name+".go")); err == nil {
+				continue
+			}
+
+			if spec.Name != nil {
+				local[spec.Name.Name] = name
+			} else {
+				local[name] = name
+			}
+		}
+
+		if len(local) == 0 {
+			continue
+		}
+
+		if f, err = parser.ParseFile(fset, filename, nil, 0); err != nil {
+			return nil, fmt.Errorf("missingEnumPackages: %w", err)
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok {
+				return true
+			}
+
+			ast.Inspect(field.Type, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+
+				if x, ok := sel.X.(*ast.Ident); ok && local[x.Name] != "" {
+					if typeNames[local[x.Name]] == nil {
+						typeNames[local[x.Name]] = make(map[string]bool)
+					}
+
+					typeNames[local[x.Name]][sel.Sel.Name] = true
+				}
+
+				return false
+			})
+
+			return true
+		})
+	}
+
+	out := make(map[string][]byte)
+
+	for name, types := range typeNames {
+		var buf bytes.Buffer
+
+		fmt.Fprintf(&buf, "package %s\n", name)
+		for _, t := range sortedKeys(types) {
+			fmt.Fprintf(&buf, "\ntype %s string\n", t)
+		}
+
+		out[filepath.Join(root, "modelenum", name, name+".go")] = buf.Bytes()
+	}
+
+	return out, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
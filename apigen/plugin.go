@@ -0,0 +1,151 @@
+package apigen
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ModelsDocumentVersion is incremented whenever the JSON form of the models
+// changes in a way that isn't backwards compatible.
+const ModelsDocumentVersion = 1
+
+// ModelsDocument is the JSON form of the models in a package, as given to
+// plugins and printed by -dump_model.
+type ModelsDocument struct {
+	Version          int      `json:"version"`
+	PackageName      string   `json:"packageName"`
+	ModelsImportPath string   `json:"modelsImportPath"`
+	Models           []*Model `json:"models"`
+}
+
+// PluginConfig describes an external program that generates code from the
+// models. The program is given a ModelsDocument on stdin, and writes a JSON
+// list of pluginResults to stdout.
+type PluginConfig struct {
+	// Name identifies the plugin in logs and filters.
+	Name string `json:"name" yaml:"name"`
+	// Command is the program to run and its arguments. In a config file, a
+	// program given as a relative path, e.g. ./tools/plugin, is relative to
+	// the file; one given as a bare name is looked up in the PATH.
+	Command []string `json:"command" yaml:"command"`
+	// Dir is the directory that relative paths in results are resolved
+	// against. It's relative to the Go output directory unless absolute, and
+	// defaults to the Go output directory.
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+type pluginResult struct {
+	File     string `json:"file"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+type PluginGenerator struct {
+	dir    string
+	cfg    *Config
+	plugin PluginConfig
+}
+
+func NewPluginGenerator(dir string, cfg *Config, plugin PluginConfig) *PluginGenerator {
+	if plugin.Dir != "" {
+		if filepath.IsAbs(plugin.Dir) {
+			dir = plugin.Dir
+		} else {
+			dir = filepath.Join(dir, plugin.Dir)
+		}
+	}
+
+	return &PluginGenerator{dir: dir, cfg: cfg, plugin: plugin}
+}
+
+func (g *PluginGenerator) Name() string {
+	return g.plugin.Name
+}
+
+// Dir is the output directory of the plugin.
+func (g *PluginGenerator) Dir() string {
+	return g.dir
+}
+
+// Models runs the plugin, returning a writer for each file it produced.
+// Unlike the built in generators, the output isn't known until the plugin has
+// run, so this can fail.
+func (g *PluginGenerator) Models(ctx context.Context, models []*Model) ([]writer, error) {
+	if len(g.plugin.Command) == 0 {
+		return nil, fmt.Errorf("PluginGenerator.Models: plugin %q has no command", g.plugin.Name)
+	}
+
+	input, err := json.Marshal(ModelsDocument{
+		Version:          ModelsDocumentVersion,
+		PackageName:      g.cfg.PackageName,
+		ModelsImportPath: g.cfg.ModelsImportPath,
+		Models:           models,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PluginGenerator.Models: couldn't encode models: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, g.plugin.Command[0], g.plugin.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("PluginGenerator.Models: plugin %q failed: %w\n%s", g.plugin.Name, err, stderr.String())
+		}
+
+		return nil, fmt.Errorf("PluginGenerator.Models: plugin %q failed: %w", g.plugin.Name, err)
+	}
+
+	if stderr.Len() > 0 {
+		os.Stderr.Write(stderr.Bytes())
+	}
+
+	var results []pluginResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		return nil, fmt.Errorf("PluginGenerator.Models: couldn't parse output of plugin %q: %w", g.plugin.Name, err)
+	}
+
+	writers := make([]writer, len(results))
+
+	for i, r := range results {
+		if r.File == "" {
+			return nil, fmt.Errorf("PluginGenerator.Models: plugin %q produced a result with no file", g.plugin.Name)
+		}
+		if r.Language == "" {
+			return nil, fmt.Errorf("PluginGenerator.Models: plugin %q produced %s with no language", g.plugin.Name, r.File)
+		}
+
+		file := filepath.FromSlash(r.File)
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(g.dir, file)
+		}
+
+		content := []byte(r.Content)
+		hash := sha256.Sum256(content)
+
+		writers[i] = &basicWriter{
+			name:     filepath.ToSlash(r.File),
+			language: r.Language,
+			file:     file,
+			hash:     hex.EncodeToString(hash[:]),
+			write: func(wr io.Writer) error {
+				_, err := wr.Write(content)
+				return err
+			},
+		}
+	}
+
+	return writers, nil
+}
@@ -0,0 +1,132 @@
+package apigen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPluginHelperProcess isn't a real test. It's run as a plugin by the
+// other tests in this file, and writes a file listing the models and their
+// processes, and a badly formatted Go file.
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("APIGEN_TEST_PLUGIN") != "1" {
+		return
+	}
+
+	var doc ModelsDocument
+	if err := json.NewDecoder(os.Stdin).Decode(&doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var list strings.Builder
+	for _, m := range doc.Models {
+		fmt.Fprintf(&list, "%s: %s\n", m.Singular, strings.Join(m.Processes, ","))
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode([]pluginResult{
+		{File: "models.md", Language: "markdown", Content: list.String()},
+		{File: "plugin_models.go", Language: "go", Content: "package " + doc.PackageName + "\nconst   PluginModels=" + fmt.Sprint(len(doc.Models)) + "\n"},
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// pluginTree is a fresh tree with the test binary configured as the "docs"
+// plugin.
+func pluginTree(t *testing.T) (string, *Config) {
+	t.Helper()
+
+	exe, err := os.Executable()
+	mustNoError(t, err)
+
+	t.Setenv("APIGEN_TEST_PLUGIN", "1")
+
+	dir, cfg := freshTree(t)
+
+	cfg.Plugins = []PluginConfig{{
+		Name:    "docs",
+		Command: []string{exe, "-test.run=^TestPluginHelperProcess$"},
+	}}
+
+	return dir, cfg
+}
+
+// pluginFiles returns the files in res produced by the docs plugin, by
+// writer name.
+func pluginFiles(res Result) map[string]File {
+	m := make(map[string]File)
+
+	for _, f := range res.Files {
+		if f.Generator == "docs" {
+			m[f.Writer] = f
+		}
+	}
+
+	return m
+}
+
+// TestGeneratePlugin checks that a plugin is given the models and that its
+// results are written like those of the built in generators.
+func TestGeneratePlugin(t *testing.T) {
+	dir, cfg := pluginTree(t)
+
+	files := pluginFiles(generateIn(t, dir, *cfg))
+
+	if assert.Contains(t, files, "models.md") {
+		f := files["models.md"]
+
+		assert.Equal(t, filepath.Join(dir, "models", "models.md"), f.Path)
+		assert.Equal(t, "_", f.Model)
+		assert.Equal(t, "markdown", f.Language)
+		assert.Contains(t, string(f.Content), "Customer: Review\n")
+		assert.Contains(t, string(f.Content), "Order: Fulfilment\n")
+	}
+
+	if assert.Contains(t, files, "plugin_models.go") {
+		f := files["plugin_models.go"]
+
+		assert.Regexp(t, `^package models\n\nconst PluginModels = \d+\n$`, string(f.Content))
+
+		d, err := ioutil.ReadFile(f.Path)
+		mustNoError(t, err)
+		assert.Equal(t, string(f.Content), string(d))
+	}
+
+	m, err := readManifest(filepath.Join(dir, "models"))
+	mustNoError(t, err)
+	if assert.NotNil(t, m) {
+		assert.Contains(t, m.Files, "models.md")
+		assert.Contains(t, m.Files, "plugin_models.go")
+	}
+}
+
+// TestGeneratePluginFilters checks that filters apply to the results of
+// plugins by generator and file name.
+func TestGeneratePluginFilters(t *testing.T) {
+	dir, cfg := pluginTree(t)
+
+	var f WriterFilter
+	mustNoError(t, f.UnmarshalText([]byte("*:docs/models.md")))
+	cfg.Filters = WriterFilterList{f}
+
+	res := generateIn(t, dir, *cfg)
+
+	if assert.Len(t, res.Files, 1) {
+		assert.Equal(t, "docs", res.Files[0].Generator)
+		assert.Equal(t, "models.md", res.Files[0].Writer)
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "models", "plugin_models.go"))
+	assert.True(t, os.IsNotExist(err), "expected plugin_models.go not to be written, got %v", err)
+}
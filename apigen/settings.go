@@ -0,0 +1,195 @@
+package apigen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/danverbraganza/varcaser/varcaser"
+)
+
+// settings are the parts of a config that affect how models are made and how
+// code is generated for them. Each run makes its own, so that runs with
+// different configs can happen at the same time.
+type settings struct {
+	upperCaseOverrides []string
+	ignoreCreate       map[string]bool
+	ignoreUpdate       map[string]bool
+	typedEnums         bool
+	types              typeRegistry
+	// templates are the ones loaded from the template directory, keyed like
+	// builtinTemplates
+	templates map[string]string
+	funcs     template.FuncMap
+	// hash summarises all of the above, so that cached outputs are
+	// regenerated when any of it changes
+	hash string
+
+	lowerCamelUpperCamelCaps *varcaser.Caser
+	lowerKebabTitleCase      *varcaser.Caser
+	lowerKebabUpperCamelCaps *varcaser.Caser
+	upperCamelLowerCamel     *varcaser.Caser
+	upperCamelLowerCamelCaps *varcaser.Caser
+	upperCamelLowerSnake     *varcaser.Caser
+}
+
+// builtinSettings are the settings of an empty config. They're used by
+// generators made with a config that hasn't been through newRun.
+var builtinSettings = func() *settings {
+	st, err := newSettings(&Config{})
+	if err != nil {
+		panic(err)
+	}
+
+	return st
+}()
+
+// newSettings makes the settings for c. Anything that c doesn't set gets the
+// built-in behaviour.
+func newSettings(c *Config) (*settings, error) {
+	st := settings{
+		upperCaseOverrides: defaultUpperCaseOverrides,
+		ignoreCreate:       defaultIgnoreCreate,
+		ignoreUpdate:       defaultIgnoreUpdate,
+		typedEnums:         c.TypedEnums,
+		types:              builtinTypes.with(c.Types),
+		templates:          map[string]string{},
+	}
+
+	if c.UpperCaseOverrides != nil {
+		st.upperCaseOverrides = c.UpperCaseOverrides
+	}
+	if c.IgnoreCreate != nil {
+		st.ignoreCreate = make(map[string]bool)
+		for _, e := range c.IgnoreCreate {
+			st.ignoreCreate[e] = true
+		}
+	}
+	if c.IgnoreUpdate != nil {
+		st.ignoreUpdate = make(map[string]bool)
+		for _, e := range c.IgnoreUpdate {
+			st.ignoreUpdate[e] = true
+		}
+	}
+
+	st.setupCasers()
+
+	st.funcs = template.FuncMap{
+		"LKUCC": func(s string) string { return st.lowerKebabUpperCamelCaps.String(s) },
+		"UCLS":  func(s string) string { return st.upperCamelLowerSnake.String(s) },
+		"Equal": func(arg1, type1, arg2, type2 string) (string, error) {
+			if type1 != type2 {
+				return "", fmt.Errorf("Equal: can't compare %s with %s", type1, type2)
+			}
+
+			return st.types.equalExpr(arg1, arg2, type1, true)
+		},
+		"NotEqual": func(arg1, type1, arg2, type2 string) (string, error) {
+			if type1 != type2 {
+				return "", fmt.Errorf("NotEqual: can't compare %s with %s", type1, type2)
+			}
+
+			return st.types.equalExpr(arg1, arg2, type1, false)
+		},
+	}
+	for k, v := range tplFunc {
+		st.funcs[k] = v
+	}
+
+	if c.TemplateDir != "" {
+		templates, err := loadTemplateOverrides(c.TemplateDir, st.funcs)
+		if err != nil {
+			return nil, fmt.Errorf("newSettings: couldn't load templates: %w", err)
+		}
+
+		st.templates = templates
+	}
+
+	hash, err := st.computeHash()
+	if err != nil {
+		return nil, fmt.Errorf("newSettings: %w", err)
+	}
+	st.hash = hash
+
+	return &st, nil
+}
+
+// computeHash summarises the settings for the output cache.
+func (st *settings) computeHash() (string, error) {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s\n%s\n%s\n%v\n", strings.Join(st.upperCaseOverrides, ","), strings.Join(sortedKeys(st.ignoreCreate), ","), strings.Join(sortedKeys(st.ignoreUpdate), ","), st.typedEnums)
+
+	if err := json.NewEncoder(h).Encode(st.types); err != nil {
+		return "", fmt.Errorf("couldn't encode types: %w", err)
+	}
+
+	names := make([]string, 0, len(st.templates))
+	for name := range st.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\n%d\n%s\n", name, len(st.templates[name]), st.templates[name])
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// setupCasers builds the casers according to upperCaseOverrides.
+func (st *settings) setupCasers() {
+	st.lowerCamelUpperCamelCaps = &varcaser.Caser{
+		From: varcaser.LowerCamelCase,
+		To:   decorateCaseConventionWithCaseOverrides(varcaser.UpperCamelCaseKeepCaps, st.upperCaseOverrides),
+	}
+	st.lowerKebabTitleCase = &varcaser.Caser{
+		From: varcaser.KebabCase,
+		To: decorateCaseConventionWithCaseOverrides(varcaser.CaseConvention{
+			JoinStyle:      varcaser.SimpleJoinStyle(" "),
+			InitialCase:    decorateWordCaseWithCaseOverrides(strings.Title, st.upperCaseOverrides),
+			SubsequentCase: decorateWordCaseWithCaseOverrides(strings.Title, st.upperCaseOverrides, lowerCaseOverrides),
+			Example:        "Upper Title Case",
+		}, st.upperCaseOverrides),
+	}
+	st.lowerKebabUpperCamelCaps = &varcaser.Caser{
+		From: varcaser.KebabCase,
+		To:   decorateCaseConventionWithCaseOverrides(varcaser.UpperCamelCase, st.upperCaseOverrides),
+	}
+	st.upperCamelLowerCamel = &varcaser.Caser{
+		From: decorateCaseConventionWithCaseOverrides(varcaser.UpperCamelCase, st.upperCaseOverrides),
+		To:   varcaser.LowerCamelCase,
+	}
+	st.upperCamelLowerCamelCaps = &varcaser.Caser{
+		From: decorateCaseConventionWithCaseOverrides(varcaser.UpperCamelCase, st.upperCaseOverrides),
+		To:   varcaser.LowerCamelCaseKeepCaps,
+	}
+	st.upperCamelLowerSnake = &varcaser.Caser{
+		From: decorateCaseConventionWithCaseOverrides(varcaser.UpperCamelCase, st.upperCaseOverrides),
+		To:   varcaser.LowerSnakeCase,
+	}
+}
+
+// template returns the template for the named writer, preferring one from
+// the template directory over the built-in one.
+func (st *settings) template(name string) string {
+	if s, ok := st.templates[name]; ok {
+		return s
+	}
+
+	return builtinTemplates[name]
+}
+
+// settingsFor returns the settings that cfg was set up with by newRun, or the
+// built-in ones if it wasn't.
+func settingsFor(cfg *Config) *settings {
+	if cfg == nil || cfg.settings == nil {
+		return builtinSettings
+	}
+
+	return cfg.settings
+}
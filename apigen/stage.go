@@ -0,0 +1,232 @@
+package apigen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// stagedFile is an output that has been written to a temporary file, waiting
+// to be renamed into place.
+type stagedFile struct {
+	tmp      string
+	filename string
+}
+
+// staging collects the outputs of a run so that they can all be moved into
+// place once every writer has succeeded, or all thrown away if any of them
+// failed. Temporary files are created in the same directory as their
+// destination, or the closest one that exists if it hasn't been made yet, so
+// that renaming them is atomic. Nothing outside of those temporary files is
+// touched until the staging is committed.
+type staging struct {
+	m       sync.Mutex
+	files   []stagedFile
+	created bool
+}
+
+// stage writes d to a temporary file that will replace filename when the
+// staging is committed.
+func (s *staging) stage(filename string, d []byte) error {
+	dir, base := filepath.Split(filename)
+
+	tmpDir, err := closestExistingDir(dir)
+	if err != nil {
+		return fmt.Errorf("staging.stage: could not find target directory: %w", err)
+	}
+
+	f, err := ioutil.TempFile(tmpDir, "."+base+".apigen-*")
+	if err != nil {
+		return fmt.Errorf("staging.stage: could not create temporary file: %w", err)
+	}
+
+	_, statErr := os.Stat(filename)
+
+	s.m.Lock()
+	s.files = append(s.files, stagedFile{tmp: f.Name(), filename: filename})
+	if os.IsNotExist(statErr) {
+		s.created = true
+	}
+	s.m.Unlock()
+
+	if _, err := f.Write(d); err != nil {
+		f.Close()
+		return fmt.Errorf("staging.stage: could not write temporary file: %w", err)
+	}
+
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return fmt.Errorf("staging.stage: could not set permissions on temporary file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("staging.stage: could not write temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// createsFiles reports whether any of the staged files don't exist yet.
+func (s *staging) createsFiles() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.created
+}
+
+// commit creates any missing directories and renames every staged file into
+// place. It's all or nothing: existing files are renamed aside before they're
+// replaced, and if anything fails, the originals are put back and the files
+// and directories created by the commit are removed again, so the outputs are
+// left as they were before the run.
+func (s *staging) commit() error {
+	s.m.Lock()
+	files := s.files
+	s.files = nil
+	s.m.Unlock()
+
+	var (
+		createdDirs []string
+		moved       []stagedFile
+		backups     = make(map[string]string)
+	)
+
+	rollback := func() {
+		for i := len(moved) - 1; i >= 0; i-- {
+			if backup, ok := backups[moved[i].filename]; ok {
+				_ = os.Rename(backup, moved[i].filename)
+			} else {
+				_ = os.Remove(moved[i].filename)
+			}
+		}
+		for _, f := range files[len(moved):] {
+			_ = os.Remove(f.tmp)
+			if backup, ok := backups[f.filename]; ok {
+				_ = os.Rename(backup, f.filename)
+			}
+		}
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			_ = os.Remove(createdDirs[i])
+		}
+	}
+
+	for _, f := range files {
+		dirs, err := makeDirs(filepath.Dir(f.filename))
+		createdDirs = append(createdDirs, dirs...)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("staging.commit: could not prepare target directory for %s: %w", f.filename, err)
+		}
+
+		backup, err := moveAside(f.filename)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("staging.commit: could not move %s aside: %w", f.filename, err)
+		}
+		if backup != "" {
+			backups[f.filename] = backup
+		}
+
+		if err := os.Rename(f.tmp, f.filename); err != nil {
+			rollback()
+			return fmt.Errorf("staging.commit: could not move %s into place: %w", f.filename, err)
+		}
+
+		moved = append(moved, f)
+	}
+
+	for _, backup := range backups {
+		_ = os.Remove(backup)
+	}
+
+	return nil
+}
+
+// moveAside renames filename to a temporary file next to it, returning the
+// name of the temporary file. It does nothing if filename doesn't exist.
+func moveAside(filename string) (string, error) {
+	if _, err := os.Lstat(filename); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	dir, base := filepath.Split(filename)
+
+	f, err := ioutil.TempFile(dir, "."+base+".apigen-orig-*")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+
+	if err := os.Rename(filename, f.Name()); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// makeDirs is like os.MkdirAll, but returns the directories that it created,
+// outermost first.
+func makeDirs(dir string) ([]string, error) {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		missing = append(missing, d)
+
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil {
+			return created, err
+		}
+
+		created = append(created, missing[i])
+	}
+
+	return created, nil
+}
+
+// closestExistingDir returns dir, or if it doesn't exist, the closest of its
+// parents that does.
+func closestExistingDir(dir string) (string, error) {
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		fi, err := os.Stat(d)
+		if err == nil {
+			if !fi.IsDir() {
+				return "", fmt.Errorf("%s is not a directory", d)
+			}
+
+			return d, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if filepath.Dir(d) == d {
+			return "", err
+		}
+	}
+}
+
+// discard removes every staged file, leaving the destinations untouched.
+func (s *staging) discard() {
+	s.m.Lock()
+	files := s.files
+	s.files = nil
+	s.m.Unlock()
+
+	for _, f := range files {
+		_ = os.Remove(f.tmp)
+	}
+}
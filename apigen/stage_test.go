@@ -0,0 +1,87 @@
+package apigen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaging(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := filepath.Join(dir, "existing.go")
+	created := filepath.Join(dir, "sub", "created.go")
+
+	mustNoError(t, ioutil.WriteFile(existing, []byte("old"), 0644))
+
+	var s staging
+	mustNoError(t, s.stage(existing, []byte("new")))
+	mustNoError(t, s.stage(created, []byte("created")))
+
+	s.discard()
+
+	d, err := ioutil.ReadFile(existing)
+	mustNoError(t, err)
+	assert.Equal(t, "old", string(d))
+	assert.NoFileExists(t, created)
+	assert.NoDirExists(t, filepath.Dir(created))
+	assertNoTemporaryFiles(t, dir)
+
+	mustNoError(t, s.stage(existing, []byte("new")))
+	mustNoError(t, s.stage(created, []byte("created")))
+	mustNoError(t, s.commit())
+
+	d, err = ioutil.ReadFile(existing)
+	mustNoError(t, err)
+	assert.Equal(t, "new", string(d))
+	d, err = ioutil.ReadFile(created)
+	mustNoError(t, err)
+	assert.Equal(t, "created", string(d))
+	assertNoTemporaryFiles(t, dir)
+}
+
+func TestStagingCommitFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := filepath.Join(dir, "existing.go")
+	created := filepath.Join(dir, "sub", "deeper", "created.go")
+	blocked := filepath.Join(dir, "blocked")
+
+	mustNoError(t, ioutil.WriteFile(existing, []byte("old"), 0644))
+	mustNoError(t, os.MkdirAll(filepath.Join(blocked, "child"), 0755))
+
+	var s staging
+	mustNoError(t, s.stage(existing, []byte("new")))
+	mustNoError(t, s.stage(created, []byte("created")))
+	mustNoError(t, s.stage(blocked, []byte("blocked")))
+
+	assert.Error(t, s.commit())
+
+	d, err := ioutil.ReadFile(existing)
+	mustNoError(t, err)
+	assert.Equal(t, "old", string(d))
+	assert.NoDirExists(t, filepath.Join(dir, "sub"))
+	assert.DirExists(t, filepath.Join(blocked, "child"))
+	assertNoTemporaryFiles(t, dir)
+}
+
+func assertNoTemporaryFiles(t *testing.T, dir string) {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*", ".*.apigen-*"))
+	mustNoError(t, err)
+	more, err := filepath.Glob(filepath.Join(dir, ".*.apigen-*"))
+	mustNoError(t, err)
+	assert.Empty(t, append(matches, more...))
+}
+
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,77 @@
+package apigen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// builtinTemplates are the templates used by writers, keyed by generator and
+// writer name. Any of them can be replaced by a file in the template
+// directory, e.g. api/individual.tmpl.
+var builtinTemplates = map[string]string{
+	"api/individual":       apiTemplate,
+	"apifilter/individual": apifilterTemplate,
+	"apifilter/aggregated": apifilterFinishTemplate,
+	"enum/individual":      enumTemplate,
+	"flow/individual":      flowTemplate,
+	"flow/aggregated/flow": flowFinishTemplate,
+	"js/individual":        jsTemplate,
+	"schema/individual":    schemaTemplate,
+	"schema/aggregated":    schemaFinishTemplate,
+	"sql/individual":       sqlTemplate,
+}
+
+// loadTemplateOverrides reads every .tmpl file under dir, making sure that
+// each one replaces a built-in template and parses successfully with funcs.
+func loadTemplateOverrides(dir string, funcs template.FuncMap) (map[string]string, error) {
+	overrides := make(map[string]string)
+
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".tmpl" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".tmpl")
+
+		if _, ok := builtinTemplates[name]; !ok {
+			var names []string
+			for k := range builtinTemplates {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+
+			return fmt.Errorf("%s doesn't match any template; options are %s", path, strings.Join(names, ", "))
+		}
+
+		d, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if _, err := template.New(path).Funcs(funcs).Parse(string(d)); err != nil {
+			return fmt.Errorf("couldn't parse override for %s: %w", name, err)
+		}
+
+		overrides[name] = string(d)
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("loadTemplateOverrides: %w", err)
+	}
+
+	return overrides, nil
+}
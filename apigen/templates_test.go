@@ -0,0 +1,81 @@
+package apigen
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTemplate writes an override for the named template into dir.
+func writeTemplate(t *testing.T, dir, name, src string) {
+	t.Helper()
+
+	filename := filepath.Join(dir, filepath.FromSlash(name)+".tmpl")
+
+	mustNoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	mustNoError(t, ioutil.WriteFile(filename, []byte(src), 0644))
+}
+
+// TestGenerateTemplateOverride checks that a template in the template
+// directory replaces the built-in one for its generator and writer, and that
+// changing it regenerates the files it's used for.
+func TestGenerateTemplateOverride(t *testing.T) {
+	dir, cfg := freshTree(t)
+
+	cfg.TemplateDir = t.TempDir()
+	writeTemplate(t, cfg.TemplateDir, "js/individual", "// {{.Model.Singular}}, from an override of {{.Model.Plural | LC}}\n")
+
+	jsFiles := func(res Result) map[string]string {
+		m := make(map[string]string)
+
+		for _, f := range res.Files {
+			if f.Generator == "js" && f.Model != "_" {
+				m[f.Model] = string(f.Content)
+			}
+		}
+
+		return m
+	}
+
+	files := jsFiles(generateIn(t, dir, *cfg))
+
+	if assert.Contains(t, files, "Customer") {
+		assert.Equal(t, "// Customer, from an override of customers\n", files["Customer"])
+	}
+
+	writeTemplate(t, cfg.TemplateDir, "js/individual", "// {{.Model.Singular}}, from a changed override\n")
+
+	files = jsFiles(generateIn(t, dir, *cfg))
+
+	if assert.Contains(t, files, "Customer") {
+		assert.Equal(t, "// Customer, from a changed override\n", files["Customer"])
+	}
+}
+
+// TestGenerateTemplateOverrideErrors checks that overrides that can't be used
+// stop generation with an error saying which file is at fault and why.
+func TestGenerateTemplateOverrideErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc, name, src, expected string
+	}{
+		{"syntax error", "api/individual", "{{.Model.Singular", `couldn't parse override for api/individual: template: `},
+		{"unknown function", "api/individual", "{{NoSuchFunc .Model.Singular}}", `function "NoSuchFunc" not defined`},
+		{"unknown template", "api/individul", "", `api/individul.tmpl doesn't match any template; options are api/individual, apifilter/aggregated, `},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, cfg := freshTree(t)
+
+			cfg.TemplateDir = t.TempDir()
+			writeTemplate(t, cfg.TemplateDir, tc.name, tc.src)
+
+			_, err := Generate(context.Background(), *cfg)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
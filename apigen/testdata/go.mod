@@ -0,0 +1,12 @@
+module movingdata.com/p/wbi
+
+go 1.22
+
+require (
+	fknsrs.biz/p/civil v0.0.0
+	fknsrs.biz/p/sqlbuilder v0.0.0
+	github.com/gorilla/mux v1.8.0
+	github.com/lib/pq v1.10.9
+	github.com/satori/go.uuid v1.2.0
+	github.com/shopspring/decimal v1.3.1
+)
@@ -0,0 +1,11 @@
+// Package apifilter is a stub of the runtime support package of the same name,
+// covering what the generated code refers to.
+package apifilter
+
+import (
+	"fknsrs.biz/p/sqlbuilder"
+)
+
+// BuildFilters turns the api_filter tagged fields of p into conditions on the
+// columns of table.
+func BuildFilters(table *sqlbuilder.Table, p interface{}) []sqlbuilder.AsExpr { return nil }
@@ -0,0 +1,59 @@
+// Package apitypes is a stub of the runtime support package of the same name,
+// covering what the generated code refers to.
+package apitypes
+
+// Model describes a model and the fields that it's made of.
+type Model struct {
+	GoName         string
+	SQLName        string
+	APIName        string
+	Fields         []*Field
+	SpecialFilters []*Filter
+}
+
+// FlattenFilters collects the filters of the fields of m.
+func (m *Model) FlattenFilters() {}
+
+// Field describes one of the fields of a model.
+type Field struct {
+	GoName  string
+	GoType  string
+	SQLName string
+	SQLType string
+	APIName string
+	APIType string
+	Array   bool
+	NotNull bool
+	Default *Default
+	Enum    []Enum
+	Filters []*Filter
+}
+
+// Default describes the value that a field is given when it's created
+// without one.
+type Default struct {
+	Kind  string
+	Value string
+}
+
+// Enum is one of the values that an enum field can have.
+type Enum struct {
+	Value string
+	Label string
+}
+
+// Filter is one of the ways that records can be searched for.
+type Filter struct {
+	Operator string
+	Name     string
+	GoName   string
+	GoType   string
+}
+
+// Relation links a field of one model to a field of another.
+type Relation struct {
+	SourceModel string
+	SourceField string
+	TargetModel string
+	TargetField string
+}
@@ -0,0 +1,17 @@
+// Package changeregistry is a stub of the runtime support package of the same
+// name, covering what the generated code refers to.
+package changeregistry
+
+import (
+	"context"
+	"net/http"
+)
+
+// Add records that the record of model with the given id has changed.
+func Add(ctx context.Context, model string, id interface{}) {}
+
+// ChangesFromRequest returns the records changed while handling r, by model.
+func ChangesFromRequest(r *http.Request) map[string][]interface{} { return nil }
+
+// RemoveFromRequest forgets a change recorded while handling r.
+func RemoveFromRequest(r *http.Request, model string, id interface{}) {}
@@ -0,0 +1,157 @@
+// Package modelutil is a stub of the runtime support package of the same name,
+// covering what the generated code and the hand written parts of the test
+// models refer to.
+package modelutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fknsrs.biz/p/sqlbuilder"
+	uuid "github.com/satori/go.uuid"
+
+	"movingdata.com/p/wbi/internal/apitypes"
+	"movingdata.com/p/wbi/internal/traceregistry"
+)
+
+type RowQueryerContext interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type QueryerContext interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type QueryerContextAndRowQueryerContext interface {
+	QueryerContext
+	RowQueryerContext
+}
+
+type ExecerContext interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type ModelContext struct {
+	handlers []interface{}
+}
+
+// GetHandlers returns the callbacks registered with c.
+func (c *ModelContext) GetHandlers() []interface{} { return c.handlers }
+
+// CallbackMatcher picks out callbacks by model, name and record ID.
+type CallbackMatcher []string
+
+func (m CallbackMatcher) Match(model, name string, id interface{}) bool { return false }
+
+func (m CallbackMatcher) MatchConsume(model, name string, id interface{}) bool { return false }
+
+type APIOptions struct {
+	SkipCallbacks  CallbackMatcher
+	ForceCallbacks CallbackMatcher
+}
+
+func APIOptionsFromRequest(r *http.Request) (*APIOptions, error) { return &APIOptions{}, nil }
+
+type AggregateCountItem struct {
+	Values []string `json:"values"`
+	Count  int      `json:"count"`
+}
+
+type AggregateCountResult struct {
+	Fields []*apitypes.Field    `json:"fields"`
+	Items  []AggregateCountItem `json:"items"`
+}
+
+func ColumnsAsExpressions(cols []*sqlbuilder.BasicColumn) []sqlbuilder.AsExpr {
+	l := make([]sqlbuilder.AsExpr, len(cols))
+	for i, c := range cols {
+		l[i] = c
+	}
+	return l
+}
+
+func DecodeStruct(v url.Values, out interface{}) error { return nil }
+
+func Equal(a, b interface{}) bool                  { return false }
+func EqualDurationSlice(a, b []time.Duration) bool { return false }
+func EqualJSON(a, b interface{}) bool              { return false }
+func EqualStringSlice(a, b []string) bool          { return false }
+func EqualTimeSlice(a, b []time.Time) bool         { return false }
+func EqualUUIDSlice(a, b []uuid.UUID) bool         { return false }
+
+// FieldMask is implemented by the generated field masks of each model.
+type FieldMask interface {
+	ModelName() string
+	Fields() []string
+}
+
+func FieldMaskTrueFields(name string, m interface{}) []string     { return nil }
+func FieldMaskUnion(m, other, out interface{})                    {}
+func FieldMaskIntersect(m, other, out interface{})                {}
+func FieldMaskMatch(m, a, b interface{}) bool                     { return false }
+func FieldMaskFrom(a, b, out interface{})                         {}
+func FieldMaskChanges(m, a, b interface{}) []traceregistry.Change { return nil }
+
+func RegisterFinder(name string, fn func(ctx context.Context, db RowQueryerContext, id interface{}, uid, euid *uuid.UUID) (interface{}, error)) {
+}
+
+func Find(ctx context.Context, name string, db RowQueryerContext, id interface{}, uid, euid *uuid.UUID) (interface{}, error) {
+	return nil, nil
+}
+
+func RecordAuditEvent(ctx context.Context, db ExecerContext, eventID uuid.UUID, t time.Time, uid, euid uuid.UUID, action, model string, id interface{}, fields map[string][]interface{}) error {
+	return nil
+}
+
+func WithPathEntry(ctx context.Context, entry string) context.Context { return ctx }
+
+func GetPath(ctx context.Context) []string { return nil }
+
+// CallbackHistoryLog records the callbacks that have run in a context.
+type CallbackHistoryLog struct{}
+
+func (l *CallbackHistoryLog) Add(model, name string, id interface{})      {}
+func (l *CallbackHistoryLog) Has(model, name string, id interface{}) bool { return false }
+
+func WithCallbackHistoryLog(ctx context.Context) (context.Context, *CallbackHistoryLog) {
+	return ctx, nil
+}
+
+// DeferredCallbackQueue holds the callbacks that run at the end of a
+// transaction.
+type DeferredCallbackQueue struct{}
+
+func (q *DeferredCallbackQueue) Run(ctx context.Context, tx *sql.Tx) error { return nil }
+
+func WithDeferredCallbackQueue(ctx context.Context) (context.Context, *DeferredCallbackQueue) {
+	return ctx, nil
+}
+
+// FieldError describes a field that broke one of the rules in its validate
+// tag.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a record fails validation, listing every
+// field that's wrong with it.
+type ValidationError struct {
+	Model  string       `json:"model"`
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	for _, f := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s %s", f.Field, f.Message))
+	}
+
+	return fmt.Sprintf("%s is invalid: %s", e.Model, strings.Join(parts, ", "))
+}
@@ -0,0 +1,16 @@
+// Package retrydb is a stub of the runtime support package of the same name,
+// covering what the generated code refers to.
+package retrydb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LinearBackoff2 runs fn in a transaction, retrying up to n times with a delay
+// of d between attempts.
+func LinearBackoff2[T any](ctx context.Context, db *sql.DB, opts *sql.TxOptions, n int, d time.Duration, fn func(ctx context.Context, tx *sql.Tx) (T, error)) (T, error) {
+	var v T
+	return v, nil
+}
@@ -0,0 +1,19 @@
+// Package sqltypes is a stub of the runtime support package of the same name,
+// covering what the generated code refers to.
+package sqltypes
+
+import (
+	"time"
+)
+
+type TimeArray []time.Time
+
+func (a *TimeArray) Scan(src interface{}) error { return nil }
+
+type DurationArray []time.Duration
+
+func (a *DurationArray) Scan(src interface{}) error { return nil }
+
+type IntPointerArray []*int
+
+func (a *IntPointerArray) Scan(src interface{}) error { return nil }
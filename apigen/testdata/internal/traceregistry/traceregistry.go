@@ -0,0 +1,58 @@
+// Package traceregistry is a stub of the runtime support package of the same
+// name, covering what the generated code refers to.
+package traceregistry
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Change describes a field whose value changed.
+type Change struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type EventModelActivity struct {
+	ID        uuid.UUID
+	Time      time.Time
+	Action    string
+	ModelType string
+	ModelID   interface{}
+	ModelData interface{}
+	Path      []string
+}
+
+type EventIteration struct {
+	ID         uuid.UUID
+	Time       time.Time
+	ObjectType string
+	ObjectID   interface{}
+	Number     int
+}
+
+type EventCallback struct {
+	ID        uuid.UUID
+	Time      time.Time
+	Name      string
+	Skipped   bool
+	Forced    bool
+	Triggered []Change
+}
+
+type EventCallbackComplete struct {
+	ID       uuid.UUID
+	Time     time.Time
+	Name     string
+	Duration time.Duration
+	Changed  []Change
+}
+
+// Enter records the start of event, returning a function that records its end.
+func Enter(ctx context.Context, event interface{}) func() { return func() {} }
+
+// Add records event.
+func Add(ctx context.Context, event interface{}) {}
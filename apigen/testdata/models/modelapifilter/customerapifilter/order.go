@@ -0,0 +1,9 @@
+package customerapifilter
+
+import (
+	"fknsrs.biz/p/sqlbuilder"
+)
+
+func CustomerSpecialOrderSurname(desc bool) []sqlbuilder.AsOrderingTerm {
+	return nil
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"fknsrs.biz/p/civil"
+	"fknsrs.biz/p/sqlbuilder"
+	"github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+
+	"movingdata.com/p/wbi/models/modelenum/customerenum"
+)
+
+// Dated is embedded in models that record when they were created and last
+// updated.
+type Dated struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Timestamps is embedded in models that also record who created and last
+// updated them.
+type Timestamps struct {
+	Dated
+	CreatorID uuid.UUID
+	UpdaterID uuid.UUID
+}
+
+// @apigen typedenums
+type Customer struct {
+	ID      uuid.UUID `sql:",findOne,findOneByID,findMultiple,create,save"`
+	Version int
+	Timestamps
+	Name            string              `api:",specialOrder:surname:Surname" validate:"required,max=255"`
+	Code            string              `validate:"required,pattern=^[A-Z0-9]{2,8}$"`
+	Email           *string             `api:",omitempty,userFilter" validate:"email"`
+	Status          customerenum.Status `enum:"|active|suspended:On%20Hold|closed" default:"active"`
+	ReferenceNumber int                 `api:",sequence:customer_reference_numbers:CUS"`
+	Tags            []string            `validate:"max=10"`
+	Balance         float64
+	CreditLimit     *float64
+	Active          bool
+	Birthday        *civil.Date
+	Metadata        json.RawMessage
+	RegionID        *uuid.UUID `api:",specialFilter:InRegionTree:inRegionTree"`
+	ContactIDs      []uuid.UUID
+	Channels        []customerenum.Channels `enum:"|email|post"`
+	internalNotes   string
+
+	ReviewStatus         customerenum.ReviewStatus `enum:"|in-progress|completed|failed"`
+	ReviewJobID          *int
+	ReviewStartedAt      *time.Time
+	ReviewDeadline       *time.Time
+	ReviewFailureMessage string
+	ReviewCompletedAt    *time.Time
+}
+
+func CustomerUserFilter(qb *sqlbuilder.SelectStatement, euid *uuid.UUID) *sqlbuilder.SelectStatement {
+	return qb
+}
+
+func CustomerSpecialFilterInRegionTree(v uuid.UUID) sqlbuilder.AsExpr {
+	return nil
+}
+
+// @apigen sql=findOne,findOneByID,create,save
+type Order struct {
+	ID      uuid.UUID
+	Version int
+	Dated
+	CustomerID               uuid.UUID  `api:",ref:Customer"`
+	ParentOrderID            *uuid.UUID `api:",ref:Order:ID"`
+	Priority                 string     `enum:"|low|normal|high:Urgent" default:"normal"`
+	Quantity                 *int       `default:"1"`
+	Discount                 *float64   `default:"0.5"`
+	Reference                uuid.UUID  `default:"uuid"`
+	PlacedAt                 time.Time  `default:"now"`
+	DueDate                  civil.Date
+	DeliveryWindow           time.Duration
+	Timeout                  *time.Duration
+	DeliveredAt              *time.Time
+	Notes                    string `api:",filter:defaults,filter:!=:NotesIsNot"`
+	ScheduledTimes           []time.Time
+	Intervals                []time.Duration
+	Quantities               []*int
+	FulfilmentStatus         string `enum:"|in-progress|completed|failed"`
+	FulfilmentJobID          *int
+	FulfilmentStartedAt      *time.Time
+	FulfilmentDeadline       *time.Time
+	FulfilmentFailureMessage string
+	FulfilmentCompletedAt    *time.Time
+	Status                   OrderStatus   `enum:"|pending|shipped|cancelled" default:"pending"`
+	Weight                   *Grams        `validate:"min=0,max=100000"`
+	Labels                   []OrderStatus `validate:"max=3"`
+	Total                    Money
+	Refunded                 *Money
+	Ignored                  string `api:"-"`
+}
+
+// @apigen sql=findOne,findOneByID,create,save
+type Invoice struct {
+	ID         uuid.UUID
+	Version    int
+	CustomerID uuid.UUID `api:",ref:Customer"`
+	Number     int64     `json:",string" validate:"min=1"`
+	Deposit    *int64    `json:",string"`
+	LineCount  int16
+	Attempts   *int32
+	Tiers      []int32
+	TaxRate    float32
+	Amount     decimal.Decimal
+	Paid       *decimal.Decimal
+	Attachment []byte
+	Signature  Signature
+	Address    Address
+	Lines      []InvoiceLine `validate:"required"`
+	Extras     map[string]decimal.Decimal
+	Billing    *Address
+}
+
+// Address is stored as json, with the invoice it's part of.
+type Address struct {
+	Street   string
+	Suburb   string
+	Postcode string `json:"postcode,omitempty"`
+	Location *struct {
+		Lat float64
+		Lng float64
+	}
+}
+
+// InvoiceLine is one of the lines of an invoice, which are stored as a json
+// array.
+type InvoiceLine struct {
+	Description string
+	Quantity    int
+	Price       decimal.Decimal
+	Tags        []string
+}
+
+// Signature is stored as binary data, like a plain []byte field.
+type Signature []byte
+
+// OrderStatus is stored as text, like a plain string field.
+type OrderStatus string
+
+// Grams is stored as an integer, like a plain int field.
+type Grams int
+
+// @apigen table=audit_log_notes noaudit nocreate noupdate sql=findMultiple
+type AuditNote struct {
+	ID      uuid.UUID
+	Message string `json:"text"`
+	Pinned  bool   `api:",filter:="`
+}
@@ -0,0 +1,13 @@
+package modelschema
+
+import (
+	"movingdata.com/p/wbi/internal/apitypes"
+)
+
+func flattenRelations(a ...[]*apitypes.Relation) []*apitypes.Relation {
+	var r []*apitypes.Relation
+	for _, e := range a {
+		r = append(r, e...)
+	}
+	return r
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/satori/go.uuid"
+	"movingdata.com/p/wbi/internal/modelutil"
+)
+
+// This file stands in for the hand written code that generated code in a
+// real models package depends on.
+
+var ErrVersionMismatch = errors.New("version mismatch")
+
+type JSContext struct {
+	ctx  context.Context
+	mctx *modelutil.ModelContext
+	tx   *sql.Tx
+	uid  uuid.UUID
+	euid uuid.UUID
+	vm   interface {
+		MakeCustomError(name, message string) error
+	}
+}
+
+// Money is mapped to a numeric column in testdata/apigen.yaml.
+type Money struct {
+	cents int64
+}
+
+func (m *Money) Scan(src interface{}) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("Money.Scan: can't scan %T", src)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+
+	m.cents = int64(f * 100)
+
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100)
+}
@@ -0,0 +1,492 @@
+package apigen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeMapping describes how a Go type is represented in everything apigen
+// generates: JS, Flow, SQL, and Swagger types, how values are scanned and
+// compared, the filters fields get by default, and how values are formatted.
+//
+// Go expressions use $1 and $2 to stand for the values involved.
+type TypeMapping struct {
+	JS      string       `json:"js" yaml:"js"`
+	Flow    string       `json:"flow" yaml:"flow"`
+	SQL     string       `json:"sql" yaml:"sql"`
+	Swagger *SwaggerType `json:"swagger" yaml:"swagger"`
+	// Equal is a Go expression that's true if $1 and $2 are equal, e.g.
+	// "$1.Equal($2)". The inequality and pointer comparisons are derived
+	// from it. The default is modelutil.Equal, which pointers are then
+	// passed to as they are.
+	Equal string `json:"equal" yaml:"equal"`
+	// SliceEqual is a Go expression comparing two slices of the type. The
+	// default is modelutil.Equal.
+	SliceEqual string `json:"slice_equal" yaml:"slice_equal"`
+	// SliceScan and PointerSliceScan are the types that slices of the type,
+	// or of pointers to it, are scanned into if pq.Array can't handle them
+	// directly. They have to be convertible to the slice type.
+	SliceScan        string `json:"slice_scan" yaml:"slice_scan"`
+	PointerSliceScan string `json:"pointer_slice_scan" yaml:"pointer_slice_scan"`
+	// Filters are the filter operators fields of the type get by default, and
+	// SliceFilters the ones fields holding a slice of it get. PointerFilters
+	// are added to Filters for pointer fields, along with is_null and
+	// is_not_null.
+	Filters        []string `json:"filters" yaml:"filters"`
+	SliceFilters   []string `json:"slice_filters" yaml:"slice_filters"`
+	PointerFilters []string `json:"pointer_filters" yaml:"pointer_filters"`
+	// Format is the fmt verb that values are printed with in error messages.
+	// The default is %v.
+	Format string `json:"format" yaml:"format"`
+	// CSV is a Go expression formatting $1 as a string for CSV exports. The
+	// default formats it with %v.
+	CSV string `json:"csv" yaml:"csv"`
+	// JSONString is set for types that are strings in JS only because fields
+	// of them are encoded with the json string option, e.g. int64, not all
+	// of whose values fit in a JS number. Fields without the option are
+	// reported.
+	JSONString bool `json:"json_string" yaml:"json_string"`
+	// Zero is a Go expression that's true if $1 is the zero value, which is
+	// how required fields are checked. Types without it can't be required.
+	Zero string `json:"zero" yaml:"zero"`
+}
+
+// override returns m with anything set in o replacing what's in m.
+func (m TypeMapping) override(o TypeMapping) TypeMapping {
+	if o.JS != "" {
+		m.JS = o.JS
+	}
+	if o.Flow != "" {
+		m.Flow = o.Flow
+	}
+	if o.SQL != "" {
+		m.SQL = o.SQL
+	}
+	if o.Swagger != nil {
+		m.Swagger = o.Swagger
+	}
+	if o.Equal != "" {
+		m.Equal = o.Equal
+	}
+	if o.SliceEqual != "" {
+		m.SliceEqual = o.SliceEqual
+	}
+	if o.SliceScan != "" {
+		m.SliceScan = o.SliceScan
+	}
+	if o.PointerSliceScan != "" {
+		m.PointerSliceScan = o.PointerSliceScan
+	}
+	if o.Filters != nil {
+		m.Filters = o.Filters
+	}
+	if o.SliceFilters != nil {
+		m.SliceFilters = o.SliceFilters
+	}
+	if o.PointerFilters != nil {
+		m.PointerFilters = o.PointerFilters
+	}
+	if o.Format != "" {
+		m.Format = o.Format
+	}
+	if o.CSV != "" {
+		m.CSV = o.CSV
+	}
+	if o.JSONString {
+		m.JSONString = true
+	}
+	if o.Zero != "" {
+		m.Zero = o.Zero
+	}
+
+	return m
+}
+
+// missing lists the facets that every type has to have, but m doesn't.
+func (m TypeMapping) missing() []string {
+	var a []string
+
+	if m.JS == "" {
+		a = append(a, "js")
+	}
+	if m.Flow == "" {
+		a = append(a, "flow")
+	}
+	if m.SQL == "" {
+		a = append(a, "sql")
+	}
+	if m.Swagger == nil {
+		a = append(a, "swagger")
+	}
+
+	return a
+}
+
+// typeRegistry holds the TypeMapping of each type apigen can generate code
+// for, keyed by package qualified name.
+type typeRegistry map[string]TypeMapping
+
+// with returns a copy of r with the types in extra added, or merged into the
+// existing ones if they're already there.
+func (r typeRegistry) with(extra map[string]TypeMapping) typeRegistry {
+	out := make(typeRegistry, len(r)+len(extra))
+
+	for k, v := range r {
+		out[k] = v
+	}
+	for k, v := range extra {
+		out[k] = out[k].override(v)
+	}
+
+	return out
+}
+
+var (
+	orderedFilters = []string{"=", "!=", "<", "<=", ">", ">="}
+	arrayFilters   = []string{"@>", "!@>", "<@", "!<@", "&&", "!&&"}
+	nullOrFilters  = []string{"is_null_or_less_than", "is_null_or_less_than_or_equal_to", "is_null_or_greater_than", "is_null_or_greater_than_or_equal_to"}
+)
+
+// builtinTypes are the types apigen knows about without any config.
+var builtinTypes = typeRegistry{
+	"string": {
+		JS:           "string",
+		Flow:         "string",
+		SQL:          "text",
+		Swagger:      &SwaggerType{Type: "string"},
+		Equal:        "$1 == $2",
+		SliceEqual:   "modelutil.EqualStringSlice($1, $2)",
+		Filters:      []string{"=", "!=", "@@", "contains", "prefix"},
+		SliceFilters: arrayFilters,
+		Format:       "%s",
+		Zero:         `$1 == ""`,
+	},
+	"int": {
+		JS:               "number",
+		Flow:             "number",
+		SQL:              "integer",
+		Swagger:          &SwaggerType{Type: "number"},
+		Equal:            "$1 == $2",
+		PointerSliceScan: "sqltypes.IntPointerArray",
+		Filters:          orderedFilters,
+		SliceFilters:     arrayFilters,
+		Format:           "%d",
+		Zero:             "$1 == 0",
+	},
+	"int64": {
+		JS:           "string",
+		Flow:         "string",
+		SQL:          "bigint",
+		Swagger:      &SwaggerType{Type: "string", Format: "int64"},
+		Equal:        "$1 == $2",
+		Filters:      orderedFilters,
+		SliceFilters: arrayFilters,
+		Format:       "%d",
+		JSONString:   true,
+		Zero:         "$1 == 0",
+	},
+	"int32": {
+		JS:           "number",
+		Flow:         "number",
+		SQL:          "integer",
+		Swagger:      &SwaggerType{Type: "integer", Format: "int32"},
+		Equal:        "$1 == $2",
+		Filters:      orderedFilters,
+		SliceFilters: arrayFilters,
+		Format:       "%d",
+		Zero:         "$1 == 0",
+	},
+	"int16": {
+		JS:           "number",
+		Flow:         "number",
+		SQL:          "smallint",
+		Swagger:      &SwaggerType{Type: "integer"},
+		Equal:        "$1 == $2",
+		Filters:      orderedFilters,
+		SliceFilters: arrayFilters,
+		Format:       "%d",
+		Zero:         "$1 == 0",
+	},
+	"float32": {
+		JS:      "number",
+		Flow:    "number",
+		SQL:     "real",
+		Swagger: &SwaggerType{Type: "number", Format: "float"},
+		Equal:   "$1 == $2",
+		Filters: orderedFilters,
+		Format:  "%v",
+		Zero:    "$1 == 0",
+	},
+	"float64": {
+		JS:      "number",
+		Flow:    "number",
+		SQL:     "double precision",
+		Swagger: &SwaggerType{Type: "number"},
+		Equal:   "$1 == $2",
+		Filters: orderedFilters,
+		Format:  "%v",
+		Zero:    "$1 == 0",
+	},
+	// decimals are strings everywhere outside of go and the database, so
+	// that they're never rounded
+	"decimal.Decimal": {
+		JS:             "string",
+		Flow:           "string",
+		SQL:            "numeric",
+		Swagger:        &SwaggerType{Type: "string", Format: "decimal"},
+		Equal:          "$1.Equal($2)",
+		Filters:        orderedFilters,
+		PointerFilters: nullOrFilters,
+		Format:         "%v",
+		Zero:           "$1.IsZero()",
+	},
+	"bool": {
+		JS:      "boolean",
+		Flow:    "boolean",
+		SQL:     "boolean",
+		Swagger: &SwaggerType{Type: "boolean"},
+		Equal:   "$1 == $2",
+		Filters: []string{"=", "!="},
+		Format:  "%t",
+	},
+	"uuid.UUID": {
+		JS:           "string",
+		Flow:         "global_uuid_UUID",
+		SQL:          "uuid",
+		Swagger:      &SwaggerType{Type: "string", Format: "uuid"},
+		Equal:        "$1 == $2",
+		SliceEqual:   "modelutil.EqualUUIDSlice($1, $2)",
+		Filters:      []string{"=", "!=", "in", "not_in"},
+		SliceFilters: arrayFilters,
+		Format:       "%s",
+		Zero:         "$1 == uuid.Nil",
+	},
+	"time.Time": {
+		JS:             "string",
+		Flow:           "global_time_Time",
+		SQL:            "timestamp with time zone",
+		Swagger:        &SwaggerType{Type: "string", Format: "date-time"},
+		Equal:          "$1.Equal($2)",
+		SliceEqual:     "modelutil.EqualTimeSlice($1, $2)",
+		SliceScan:      "sqltypes.TimeArray",
+		Filters:        orderedFilters,
+		PointerFilters: nullOrFilters,
+		Format:         "%v",
+		Zero:           "$1.IsZero()",
+	},
+	"time.Duration": {
+		JS:             "string",
+		Flow:           "global_time_Duration",
+		SQL:            "integer",
+		Swagger:        &SwaggerType{Type: "string"},
+		Equal:          "$1 == $2",
+		SliceEqual:     "modelutil.EqualDurationSlice($1, $2)",
+		SliceScan:      "sqltypes.DurationArray",
+		Filters:        orderedFilters,
+		PointerFilters: nullOrFilters,
+		Format:         "%v",
+		Zero:           "$1 == 0",
+	},
+	"civil.Date": {
+		JS:             "string",
+		Flow:           "global_civil_Date",
+		SQL:            "date",
+		Swagger:        &SwaggerType{Type: "string", Format: "date"},
+		Equal:          "$1.On($2)",
+		Filters:        orderedFilters,
+		PointerFilters: nullOrFilters,
+		Format:         "%v",
+		Zero:           "$1 == (civil.Date{})",
+	},
+	// byte slices are base64 encoded in JSON, and can only be filtered on as
+	// a whole
+	"[]byte": {
+		JS:      "string",
+		Flow:    "string",
+		SQL:     "bytea",
+		Swagger: &SwaggerType{Type: "string", Format: "byte"},
+		Equal:   "bytes.Equal($1, $2)",
+		Filters: []string{"is_null", "is_not_null", "sha256"},
+		Format:  "%x",
+		CSV:     "base64.StdEncoding.EncodeToString($1)",
+		Zero:    "len($1) == 0",
+	},
+	"json.RawMessage": {
+		JS:      "any",
+		Flow:    "any",
+		SQL:     "json",
+		Swagger: &SwaggerType{Type: "any"},
+		Equal:   "modelutil.EqualJSON($1, $2)",
+		Format:  "%s",
+		Zero:    "len($1) == 0",
+	},
+}
+
+// expand substitutes arg1 and arg2 into expr. If deref is set, the arguments
+// are pointers, so they're dereferenced unless a method is being called on
+// them.
+func expand(expr, arg1, arg2 string, deref bool) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(expr); i++ {
+		if expr[i] != '$' || i+1 == len(expr) || (expr[i+1] != '1' && expr[i+1] != '2') {
+			sb.WriteByte(expr[i])
+			continue
+		}
+
+		arg := arg1
+		if expr[i+1] == '2' {
+			arg = arg2
+		}
+
+		i++
+
+		if deref && (i+1 == len(expr) || expr[i+1] != '.') {
+			sb.WriteByte('*')
+		}
+
+		sb.WriteString(arg)
+	}
+
+	return sb.String()
+}
+
+// equalExpr returns a Go expression comparing arg1 and arg2, which are both
+// of typ: a registered type, a pointer to or slice of one, or "any". If equal
+// isn't set, the expression is true when they differ instead.
+func (r typeRegistry) equalExpr(arg1, arg2, typ string, equal bool) (string, error) {
+	not := "!"
+	if equal {
+		not = ""
+	}
+
+	if typ == "any" {
+		return fmt.Sprintf("%smodelutil.Equal(%s, %s)", not, arg1, arg2), nil
+	}
+
+	// []byte is registered as a type of its own, rather than as a slice
+	if _, ok := r[typ]; !ok && strings.HasPrefix(typ, "[]") {
+		elem := strings.TrimPrefix(typ, "[]")
+
+		if m, ok := r[elem]; ok && m.SliceEqual != "" {
+			return not + expand(m.SliceEqual, arg1, arg2, false), nil
+		}
+
+		return fmt.Sprintf("%smodelutil.Equal(%s, %s)", not, arg1, arg2), nil
+	}
+
+	elem := strings.TrimPrefix(typ, "*")
+
+	m, ok := r[elem]
+	if !ok {
+		return "", fmt.Errorf("equalExpr: no way of comparing values of type %s", typ)
+	}
+
+	if m.Equal == "" {
+		return fmt.Sprintf("%smodelutil.Equal(%s, %s)", not, arg1, arg2), nil
+	}
+
+	expr := m.Equal
+	if !equal {
+		if expr == "$1 == $2" {
+			expr = "$1 != $2"
+		} else {
+			expr = "!" + expr
+		}
+	}
+
+	if elem == typ {
+		return expand(expr, arg1, arg2, false), nil
+	}
+
+	cmp := expand(expr, arg1, arg2, true)
+
+	if equal {
+		return fmt.Sprintf("((%s == nil && %s == nil) || (%s != nil && %s != nil && %s))", arg1, arg2, arg1, arg2, cmp), nil
+	}
+
+	return fmt.Sprintf("((%s == nil && %s != nil) || (%s != nil && %s == nil) || (%s != nil && %s != nil && %s))", arg1, arg2, arg1, arg2, arg1, arg2, cmp), nil
+}
+
+type SwaggerType struct {
+	Type     string        `json:"type"`
+	Format   string        `json:"format,omitempty"`
+	Nullable bool          `json:"nullable,omitempty"`
+	Enum     []interface{} `json:"enum,omitempty"`
+	Items    *SwaggerType  `json:"items,omitempty"`
+	// Properties and AdditionalProperties describe objects, i.e. structs and
+	// maps stored as jsonb.
+	Properties           map[string]*SwaggerType `json:"properties,omitempty"`
+	AdditionalProperties *SwaggerType            `json:"additionalProperties,omitempty"`
+	// the rest are limits on values, from fields' validate tags.
+	MinLength *float64 `json:"minLength,omitempty"`
+	MaxLength *float64 `json:"maxLength,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	MinItems  *float64 `json:"minItems,omitempty"`
+	MaxItems  *float64 `json:"maxItems,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	// Default is the value fields get from their default tag, if it's a
+	// literal.
+	Default interface{} `json:"default,omitempty"`
+}
+
+func (t *SwaggerType) toMap() map[string]interface{} {
+	if t.Type == "any" {
+		return map[string]interface{}{}
+	}
+
+	m := map[string]interface{}{"type": t.Type}
+
+	if t.Format != "" {
+		m["format"] = t.Format
+	}
+
+	if t.Nullable {
+		m["nullable"] = t.Nullable
+	}
+
+	if t.Enum != nil {
+		m["enum"] = t.Enum
+	}
+
+	if t.Items != nil {
+		m["items"] = t.Items.toMap()
+	}
+
+	if t.Properties != nil {
+		properties := make(map[string]interface{}, len(t.Properties))
+		for k, v := range t.Properties {
+			properties[k] = v.toMap()
+		}
+
+		m["properties"] = properties
+	}
+
+	if t.AdditionalProperties != nil {
+		m["additionalProperties"] = t.AdditionalProperties.toMap()
+	}
+
+	for k, v := range map[string]*float64{
+		"minLength": t.MinLength,
+		"maxLength": t.MaxLength,
+		"minimum":   t.Minimum,
+		"maximum":   t.Maximum,
+		"minItems":  t.MinItems,
+		"maxItems":  t.MaxItems,
+	} {
+		if v != nil {
+			m[k] = *v
+		}
+	}
+
+	if t.Pattern != "" {
+		m["pattern"] = t.Pattern
+	}
+
+	if t.Default != nil {
+		m["default"] = t.Default
+	}
+
+	return m
+}
@@ -0,0 +1,273 @@
+package apigen
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validation is what a field's validate tag asks of its values, e.g.
+// `validate:"required,min=1,max=255,email,pattern=^[a-z0-9.@]+$"`, along with
+// the checks that are generated for it.
+type Validation struct {
+	Required bool     `json:"required,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Email    bool     `json:"email,omitempty"`
+
+	Checks []ValidationCheck `json:"checks"`
+}
+
+// ValidationCheck is one of the rules that values of a field are checked
+// against. Go and JS are expressions that are true if $1 breaks the rule.
+type ValidationCheck struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Go      string `json:"go"`
+	JS      string `json:"js"`
+}
+
+// emailPattern is deliberately loose; it's only meant to catch values that
+// are obviously not email addresses.
+const emailPattern = `^[^@\s]+@[^@\s]+\.[^@\s]+$`
+
+var numericTypes = map[string]bool{
+	"int":     true,
+	"int64":   true,
+	"int32":   true,
+	"int16":   true,
+	"float32": true,
+	"float64": true,
+}
+
+// parseValidation parses a validate tag. The pattern rule takes the rest of
+// the tag, so that patterns can have commas in them, which means it has to
+// come last.
+func parseValidation(tag string) (*Validation, error) {
+	var v Validation
+
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, arg, hasArg := strings.Cut(rule, "=")
+
+		switch name {
+		case "required", "email":
+			if hasArg {
+				return nil, fmt.Errorf("%s doesn't take a value", name)
+			}
+
+			if name == "required" {
+				v.Required = true
+			} else {
+				v.Email = true
+			}
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s needs a number, e.g. %s=1", name, name)
+			}
+
+			if name == "min" {
+				v.Min = &n
+			} else {
+				v.Max = &n
+			}
+		case "pattern":
+			if next := ruleAfterComma(arg); next != "" {
+				return nil, fmt.Errorf("pattern has to be the last rule, as it takes the rest of the tag, but it's followed by %s", next)
+			}
+
+			if _, err := regexp.Compile(arg); err != nil {
+				return nil, fmt.Errorf("pattern isn't a valid regular expression: %v", err)
+			}
+
+			v.Pattern = arg
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+
+	if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+		return nil, fmt.Errorf("min is greater than max")
+	}
+
+	return &v, nil
+}
+
+// ruleAfterComma returns the first rule found after a comma in a pattern,
+// which is almost certainly meant to be a rule of its own rather than part of
+// the pattern.
+func ruleAfterComma(pattern string) string {
+	for _, s := range strings.Split(pattern, ",")[1:] {
+		rule, _, _ := strings.Cut(s, ",")
+
+		switch name, _, _ := strings.Cut(rule, "="); name {
+		case "required", "email", "min", "max", "pattern":
+			return rule
+		}
+	}
+
+	return ""
+}
+
+// build works out the checks for a field whose values are represented as
+// mapType, and described by mapping. convert is set if the field has a named
+// type that has to be converted to mapType to be measured or matched.
+// patternVar and emailVar are the names that the regular expressions for
+// the pattern and email rules are declared with.
+func (v *Validation) build(mapType string, mapping TypeMapping, isSlice, isPointer, convert bool, patternVar, emailVar string) error {
+	v.Checks = nil
+
+	// the other rules are only checked if there's a value, which required
+	// takes care of for non-pointers
+	value, guard := "$1", ""
+	if isPointer && !isSlice {
+		value, guard = "*$1", "$1 != nil && "
+	}
+
+	str := value
+	if convert {
+		str = "string(" + value + ")"
+	}
+
+	if v.Required {
+		c := ValidationCheck{Rule: "required", Message: "is required", JS: "$1 == null"}
+
+		switch {
+		case isSlice:
+			c.Go, c.JS = "len($1) == 0", "$1 == null || $1.length === 0"
+		case isPointer:
+			c.Go = "$1 == nil"
+		case mapping.Zero == "":
+			return fmt.Errorf("required doesn't apply to %s fields, as they don't have a zero value apigen knows about", mapType)
+		default:
+			c.Go = mapping.Zero
+
+			switch {
+			case mapping.JSONString:
+				c.JS = "$1 == null || Number($1) === 0"
+			case mapping.JS == "string":
+				c.JS = "$1 == null || $1 === ''"
+			case mapping.JS == "number":
+				c.JS = "$1 == null || $1 === 0"
+			}
+		}
+
+		v.Checks = append(v.Checks, c)
+	}
+
+	for _, bound := range []struct {
+		rule  string
+		n     *float64
+		op    string
+		least string
+	}{
+		{"min", v.Min, "<", "least"},
+		{"max", v.Max, ">", "most"},
+	} {
+		if bound.n == nil {
+			continue
+		}
+
+		n := strconv.FormatFloat(*bound.n, 'f', -1, 64)
+		c := ValidationCheck{Rule: bound.rule}
+
+		switch {
+		case isSlice:
+			if *bound.n < 0 || *bound.n != math.Trunc(*bound.n) {
+				return fmt.Errorf("%s for a slice has to be a whole number of items", bound.rule)
+			}
+
+			c.Message = fmt.Sprintf("must have at %s %s items", bound.least, n)
+			c.Go = "len($1) " + bound.op + " " + n
+			c.JS = "$1 != null && $1.length " + bound.op + " " + n
+		case mapType == "string":
+			if *bound.n < 0 || *bound.n != math.Trunc(*bound.n) {
+				return fmt.Errorf("%s for a string has to be a whole number of characters", bound.rule)
+			}
+
+			c.Message = fmt.Sprintf("must be at %s %s characters long", bound.least, n)
+			c.Go = guard + "utf8.RuneCountInString(" + str + ") " + bound.op + " " + n
+			c.JS = "$1 != null && [...$1].length " + bound.op + " " + n
+		case numericTypes[mapType]:
+			if !strings.HasPrefix(mapType, "float") && *bound.n != math.Trunc(*bound.n) {
+				return fmt.Errorf("%s for %s fields has to be a whole number", bound.rule, mapType)
+			}
+
+			c.Message = fmt.Sprintf("must be at %s %s", bound.least, n)
+			c.Go = guard + value + " " + bound.op + " " + n
+			c.JS = "$1 != null && $1 " + bound.op + " " + n
+			if mapping.JSONString {
+				c.JS = "$1 != null && Number($1) " + bound.op + " " + n
+			}
+		default:
+			return fmt.Errorf("%s applies to strings, numbers, and slices, not %s", bound.rule, mapType)
+		}
+
+		v.Checks = append(v.Checks, c)
+	}
+
+	email := ""
+	if v.Email {
+		email = emailPattern
+	}
+
+	for _, match := range []struct {
+		rule, pattern, message, name string
+	}{
+		{"pattern", v.Pattern, "must match " + v.Pattern, patternVar},
+		{"email", email, "must be an email address", emailVar},
+	} {
+		if match.pattern == "" {
+			continue
+		}
+
+		if isSlice || mapType != "string" {
+			return fmt.Errorf("%s only applies to strings, not %s", match.rule, mapType)
+		}
+
+		v.Checks = append(v.Checks, ValidationCheck{
+			Rule:    match.rule,
+			Message: match.message,
+			Go:      guard + value + ` != "" && !` + match.name + ".MatchString(" + str + ")",
+			JS:      "$1 != null && $1 !== '' && !new RegExp(" + jsString(match.pattern) + ").test($1)",
+		})
+	}
+
+	return nil
+}
+
+// describe returns a copy of t, the Swagger type of a field, with the limits
+// that v puts on its values.
+func (v *Validation) describe(t *SwaggerType, mapType string, isSlice bool) *SwaggerType {
+	d := *t
+
+	switch {
+	case isSlice:
+		d.MinItems, d.MaxItems = v.Min, v.Max
+	case mapType == "string":
+		d.MinLength, d.MaxLength = v.Min, v.Max
+		d.Pattern = v.Pattern
+		if v.Email {
+			d.Format = "email"
+		}
+	case numericTypes[mapType]:
+		d.Minimum, d.Maximum = v.Min, v.Max
+	}
+
+	return &d
+}
+
+// jsString quotes s as a single quoted JS string.
+func jsString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`).Replace(s) + "'"
+}
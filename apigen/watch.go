@@ -0,0 +1,280 @@
+package apigen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Watch generates code like Generate, then polls the source files of the
+// model packages every interval and generates it again whenever they change,
+// until ctx is done. After the first run, only the writers for models that
+// have changed are run again, along with the aggregated writers and plugins.
+//
+// The config is made by load, which is called again whenever the config file
+// or any of the template overrides change, after which everything is
+// generated again from scratch.
+//
+// The outcome of each run is passed to report. Problems with the sources, the
+// models, or a changed config don't stop Watch, as they're usually just a file
+// that's halfway through being edited; it returns early only if the first
+// config can't be used at all.
+func Watch(ctx context.Context, load func() (*Config, error), interval time.Duration, report func(Result, error)) error {
+	cfg, err := load()
+	if err != nil {
+		return fmt.Errorf("Watch: %w", err)
+	}
+
+	r, err := newRun(cfg)
+	if err != nil {
+		return fmt.Errorf("Watch: %w", err)
+	}
+
+	// previous holds the encoded form of each model as of the last
+	// successful run, to work out which ones have changed since
+	var previous map[string]string
+
+	for {
+		dirs, err := r.sourceDirs(ctx)
+		if err != nil {
+			report(Result{}, err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+				continue
+			}
+		}
+
+		before := sourceSnapshot(dirs)
+		beforeConfig := configSnapshot(r.cfg)
+
+		r.result = Result{}
+
+		current, err := r.watchOnce(ctx, previous)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			previous = current
+		}
+
+		report(r.result, err)
+
+		r.l.WithField("packages", len(dirs)).Info("waiting for changes")
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+
+			afterConfig := configSnapshot(r.cfg)
+			if changed := changedSources(beforeConfig, afterConfig, nil); len(changed) > 0 {
+				r.l.WithField("files", changed).Info("config changed")
+
+				beforeConfig = afterConfig
+
+				nr, err := reloadRun(load)
+				if err != nil {
+					report(Result{}, err)
+					continue
+				}
+
+				r = nr
+				previous = nil
+
+				break wait
+			}
+
+			if changed := changedSources(before, sourceSnapshot(dirs), generatedSources(dirs)); len(changed) > 0 {
+				r.l.WithField("files", changed).Info("source files changed")
+				break
+			}
+		}
+	}
+}
+
+// reloadRun makes a new run from a freshly loaded config.
+func reloadRun(load func() (*Config, error)) (*run, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't reload config: %w", err)
+	}
+
+	r, err := newRun(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't reload config: %w", err)
+	}
+
+	return r, nil
+}
+
+// watchOnce loads the packages and generates code for the models that differ
+// from previous, or for all of them if previous is nil. It returns the
+// encoded form of each of the models it found.
+func (r *run) watchOnce(ctx context.Context, previous map[string]string) (map[string]string, error) {
+	pkgs, modelsByPackage, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]string)
+
+	r.changed = nil
+	if previous != nil {
+		r.changed = make(map[string]bool)
+	}
+
+	for _, pkg := range pkgs {
+		for _, model := range modelsByPackage[pkg] {
+			d, err := json.Marshal(model)
+			if err != nil {
+				return nil, fmt.Errorf("watchOnce: couldn't encode model %s: %w", model.Singular, err)
+			}
+
+			key := pkg.Types.Path() + "." + model.Singular
+
+			current[key] = string(d)
+
+			if previous != nil && previous[key] != current[key] {
+				r.l.WithField("model", model.Singular).Info("model changed")
+				r.changed[model.Singular] = true
+			}
+		}
+	}
+
+	if err := r.generate(ctx, pkgs, modelsByPackage); err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// sourceDirs returns the directories containing the packages matching the
+// patterns being watched. Unlike loading the packages fully, this works even
+// if their files don't parse or type-check.
+func (r *run) sourceDirs(ctx context.Context) ([]string, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Dir:     r.cfg.Dir,
+		Mode:    packages.NeedName | packages.NeedFiles,
+	}, r.cfg.Patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("sourceDirs: couldn't list packages: %w", err)
+	}
+
+	var dirs []string
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) > 0 {
+			dirs = append(dirs, filepath.Dir(pkg.GoFiles[0]))
+		}
+	}
+
+	return dirs, nil
+}
+
+// sourceState is what's used to tell whether a source file has changed.
+type sourceState struct {
+	modTime int64
+	size    int64
+}
+
+// sourceSnapshot records the state of the go files in dirs, excluding tests.
+func sourceSnapshot(dirs []string) map[string]sourceState {
+	m := make(map[string]sourceState)
+
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, f := range files {
+			if f.IsDir() || !strings.HasSuffix(f.Name(), ".go") || strings.HasSuffix(f.Name(), "_test.go") {
+				continue
+			}
+
+			m[filepath.Join(dir, f.Name())] = sourceState{modTime: f.ModTime().UnixNano(), size: f.Size()}
+		}
+	}
+
+	return m
+}
+
+// configSnapshot records the state of the config file and the template
+// overrides that cfg was made from.
+func configSnapshot(cfg *Config) map[string]sourceState {
+	m := make(map[string]sourceState)
+
+	if cfg.File != "" {
+		if fi, err := os.Stat(cfg.File); err == nil {
+			m[cfg.File] = sourceState{modTime: fi.ModTime().UnixNano(), size: fi.Size()}
+		}
+	}
+
+	if cfg.TemplateDir != "" {
+		_ = filepath.Walk(cfg.TemplateDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if !info.IsDir() && filepath.Ext(path) == ".tmpl" {
+				m[path] = sourceState{modTime: info.ModTime().UnixNano(), size: info.Size()}
+			}
+
+			return nil
+		})
+	}
+
+	return m
+}
+
+// generatedSources returns the files in dirs that are listed in their output
+// manifests, i.e. the ones apigen wrote itself.
+func generatedSources(dirs []string) map[string]bool {
+	m := make(map[string]bool)
+
+	for _, dir := range dirs {
+		mf, err := readManifest(dir)
+		if err != nil || mf == nil {
+			continue
+		}
+
+		for _, rel := range mf.Files {
+			m[filepath.Join(dir, filepath.FromSlash(rel))] = true
+		}
+	}
+
+	return m
+}
+
+// changedSources lists the files that were added, removed, or modified
+// between two snapshots, other than those in ignore.
+func changedSources(before, after map[string]sourceState, ignore map[string]bool) []string {
+	var changed []string
+
+	for filename, a := range after {
+		if b, ok := before[filename]; (!ok || a != b) && !ignore[filename] {
+			changed = append(changed, filename)
+		}
+	}
+
+	for filename := range before {
+		if _, ok := after[filename]; !ok && !ignore[filename] {
+			changed = append(changed, filename)
+		}
+	}
+
+	return changed
+}
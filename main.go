@@ -1,156 +1,65 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"go/types"
-	"io/ioutil"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"regexp"
-	"strings"
+	"runtime"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"golang.org/x/tools/go/packages"
-	"golang.org/x/tools/imports"
-)
-
-func match(pattern, value string) bool {
-	pattern = strings.ReplaceAll(pattern, ".", "\\.")
-	pattern = strings.ReplaceAll(pattern, "*", ".*")
-	pattern = strings.ReplaceAll(pattern, "?", ".")
-	match, _ := regexp.MatchString("^"+pattern+"$", value)
-	return match
-}
-
-func matchAny(patterns []string, value string) bool {
-	if len(patterns) == 0 {
-		return true
-	}
-
-	for _, e := range patterns {
-		if match(e, value) {
-			return true
-		}
-	}
-
-	return false
-}
-
-type filter struct {
-	modelFilters  []string
-	writerFilters []string
-}
-
-func (f *filter) match(modelName, writerName string) bool {
-	return matchAny(f.modelFilters, modelName) && matchAny(f.writerFilters, writerName)
-}
-
-func (f *filter) String() string {
-	if len(f.writerFilters) == 0 {
-		return strings.Join(f.modelFilters, ",")
-	}
-
-	return strings.Join(f.modelFilters, ",") + ":" + strings.Join(f.writerFilters, ",")
-}
-
-func (f *filter) UnmarshalText(d []byte) error {
-	sets := strings.Split(string(d), ":")
-	if len(sets) > 2 {
-		return fmt.Errorf("too many sets of filters")
-	}
-
-	if len(sets) > 0 && sets[0] != "" {
-		f.modelFilters = strings.Split(sets[0], ",")
-	}
-	if len(sets) > 1 && sets[1] != "" {
-		f.writerFilters = strings.Split(sets[1], ",")
-	}
-
-	return nil
-}
-
-func (f *filter) MarshalText() ([]byte, error) {
-	return []byte(f.String()), nil
-}
 
-type filterList []filter
-
-func (l filterList) match(modelName, writerName string) bool {
-	if len(l) == 0 {
-		return true
-	}
-
-	for _, e := range l {
-		if e.match(modelName, writerName) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (l *filterList) String() string {
-	return fmt.Sprint(*l)
-}
-
-func (l *filterList) Set(s string) error {
-	var f filter
-	if err := f.UnmarshalText([]byte(s)); err != nil {
-		return err
-	}
-
-	*l = append(*l, f)
-
-	return nil
-}
+	"movingdata.com/p/wbiapigen/apigen/apigen"
+)
 
 var (
 	flagLogLevel          string
+	flagConfig            string
 	flagGoDir             string
 	flagJSDir             string
 	flagFlowDir           string
-	flagFilters           filterList
+	flagTemplateDir       string
+	flagFilters           apigen.WriterFilterList
 	flagDry               bool
+	flagCheck             bool
+	flagJobs              int
+	flagPrune             bool
+	flagForce             bool
 	flagDisableFormatting bool
 	flagAllowSourceErrors bool
+	flagDiagnostics       string
+	flagDumpModel         string
+	flagWatch             bool
+	flagWatchInterval     time.Duration
 )
 
 func init() {
 	flag.StringVar(&flagLogLevel, "log_level", "info", "Log level (options are panic, fatal, error, warn, info, debug, trace).")
+	flag.StringVar(&flagConfig, "config", "", "Config file to load (default is apigen.yaml, apigen.yml, or apigen.json in the working directory, if present).")
 	flag.StringVar(&flagGoDir, "go_dir", "", "Directory to output model code to (default is the same directory as the source files).")
-	flag.StringVar(&flagJSDir, "js_dir", "", "Directory to output JavaScript code to (default is ../client/src relative to the source files).")
-	flag.StringVar(&flagFlowDir, "flow_dir", "", "Directory to output Flow code to (default is ../static/flow/lib relative to the source files).")
+	flag.StringVar(&flagJSDir, "js_dir", "", "Directory to output JavaScript code to (default is ../client/src relative to the source files, or js_dir from the config file).")
+	flag.StringVar(&flagFlowDir, "flow_dir", "", "Directory to output Flow code to (default is ../static/flow/lib relative to the source files, or flow_dir from the config file).")
+	flag.StringVar(&flagTemplateDir, "template_dir", "", "Directory of templates to use instead of the built-in ones, e.g. api/individual.tmpl (default is template_dir from the config file).")
 	flag.Var(&flagFilters, "filter", "Filter to only the specified models and writers.")
 	flag.BoolVar(&flagDry, "dry", false, "Dry run (don't write files).")
+	flag.BoolVar(&flagCheck, "check", false, "Check that generated files are up to date (don't write files, print a diff for each changed file and exit with an error if any differ).")
+	flag.IntVar(&flagJobs, "j", runtime.NumCPU(), "Number of writers to run concurrently.")
+	flag.BoolVar(&flagPrune, "prune", false, "Delete generated files that are listed in an output manifest but no longer produced by any writer.")
+	flag.BoolVar(&flagForce, "force", false, "Run all writers, even those whose output is known to be up to date.")
 	flag.BoolVar(&flagDisableFormatting, "disable_formatting", false, "Disable formatting (if applicable).")
+	flag.StringVar(&flagDiagnostics, "diagnostics", "text", "Format to print problems found in models in (options are text, json).")
+	flag.StringVar(&flagDumpModel, "dump_model", "", "Print the models matching these comma separated patterns (e.g. Widget,Order*) as JSON instead of generating code.")
+	flag.BoolVar(&flagWatch, "watch", false, "Keep running, generating code again whenever the source files of the model packages change.")
+	flag.DurationVar(&flagWatchInterval, "watch_interval", time.Second, "How often to check for changes to source files in watch mode.")
 	flag.BoolVar(&flagAllowSourceErrors, "allow_source_errors", false, "Don't exit when errors are found in source packages.")
 }
 
-func logTime(l *logrus.Entry, s string, fn func()) {
-	l = l.WithField("operation", s)
-
-	a := time.Now()
-
-	l = l.WithField("time_start", a)
-
-	l.Debug("starting")
-
-	fn()
-
-	b := time.Now()
-
-	l.WithFields(logrus.Fields{
-		"time_end":   b,
-		"time_total": b.Sub(a),
-	}).Debug("finished")
-}
-
 func main() {
 	flag.Parse()
 
@@ -162,251 +71,155 @@ func main() {
 
 	l := logrus.NewEntry(logrus.StandardLogger())
 
-	i := 0
-
-	cfg := packages.Config{
-		Mode: packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps | packages.NeedFiles,
-		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
-			i++
-			fmt.Printf("\r%d files", i)
-			return parser.ParseFile(fset, filename, src, parser.ParseComments)
-		},
-	}
-
-	pkgs, err := packages.Load(&cfg, flag.Args()...)
+	cfg, err := loadConfig(l)
 	if err != nil {
-		l.WithError(err).Fatal("couldn't load packages")
+		l.WithError(err).Fatal("couldn't load config")
 	}
 
-	fmt.Printf("\n")
-
-	var foundErrors = false
-
-	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
-	loop:
-		for _, err := range pkg.Errors {
-			switch {
-			// this happens when we remove a field
-			case strings.Contains(err.Error(), "_api.go:") && strings.Contains(err.Error(), "has no field or method"):
-				continue loop
-			// this happens when we remove a user filter
-			case strings.Contains(err.Error(), "_api.go:") && strings.Contains(err.Error(), "undefined:") && strings.Contains(err.Error(), "UserFilter"):
-				continue loop
-			default:
-				// nothing
-			}
+	ctx := context.Background()
 
-			l.WithError(err).Error("error found in package")
-
-			foundErrors = true
-		}
-	})
-
-	if foundErrors && !flagAllowSourceErrors {
-		l.Fatal("errors found in package(s)")
-	}
-
-	for _, pkg := range pkgs {
-		l := l.WithField("package", pkg.Types.Name())
-
-		goDir := flagGoDir
-		if goDir == "" {
-			goDir = pkg.PkgPath
-		}
-		if goDir == "" {
-			goDir = filepath.Dir(pkg.GoFiles[0])
-		}
-		if goDir == "" {
-			l.Fatal("could not determine go directory")
-		}
-
-		jsDir := flagJSDir
-		if jsDir == "" {
-			jsDir = filepath.Join(goDir, "../client/src")
-		}
-
-		flowDir := flagFlowDir
-		if flowDir == "" {
-			flowDir = filepath.Join(goDir, "../static/flow/lib")
-		}
-
-		generatorList := []generator{
-			NewAPIGenerator(goDir),
-			NewAPIFilterGenerator(goDir),
-			NewEnumGenerator(goDir),
-			NewJSGenerator(jsDir),
-			NewFlowGenerator(flowDir),
-			NewSchemaGenerator(goDir),
-			NewSQLGenerator(goDir),
+	if flagDumpModel != "" {
+		var filters apigen.WriterFilterList
+		if err := filters.Set(flagDumpModel); err != nil {
+			l.WithError(err).Fatal("couldn't parse models to dump")
 		}
+		cfg.Filters = filters
 
-		packageName := pkg.Types.Name()
-		if packageName == "" {
-			l.Fatal("couldn't determine package name")
+		docs, err := apigen.Models(ctx, *cfg)
+		if err != nil {
+			fatal(l, err)
 		}
 
-		var models []*Model
+		printDiagnostics(l, nil)
 
-		for _, typeName := range pkg.Types.Scope().Names() {
-			l := l.WithField("model", typeName)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
 
-			obj := pkg.Types.Scope().Lookup(typeName)
-
-			namedType, ok := obj.Type().(*types.Named)
-			if !ok {
-				continue
-			}
-
-			structType, ok := namedType.Underlying().(*types.Struct)
-			if !ok {
-				continue
-			}
-
-			var file *ast.File
-			for _, f := range pkg.Syntax {
-				if obj.Pos() >= f.Pos() && obj.Pos() <= f.End() {
-					file = f
-					break
-				}
-			}
-
-			if file == nil {
-				continue
+		for _, doc := range docs {
+			if err := enc.Encode(doc); err != nil {
+				l.WithError(err).Fatal("could not dump models")
 			}
+		}
 
-			astObject := file.Scope.Lookup(obj.Name())
-			if astObject == nil {
-				continue
-			}
+		return
+	}
 
-			var hasComment bool
+	if flagWatch {
+		if flagCheck {
+			l.Fatal("watch mode can't be used with -check")
+		}
 
-			for _, comment := range file.Comments {
-				if strings.TrimSpace(comment.Text()) != "@apigen" {
-					continue
-				}
+		ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+		defer cancel()
 
-				if pkg.Fset.Position(comment.End()).Line == pkg.Fset.Position(obj.Pos()).Line-1 {
-					hasComment = true
-					break
-				}
-			}
+		load := func() (*apigen.Config, error) { return loadConfig(l) }
 
-			if !hasComment {
-				continue
+		if err := apigen.Watch(ctx, load, flagWatchInterval, func(res apigen.Result, err error) {
+			if err == nil {
+				printDiagnostics(l, nil)
+				return
 			}
 
-			model, err := makeModel(typeName, namedType, structType)
-			if err != nil {
-				l.WithError(err).Fatal("could not make model object")
+			var d apigen.DiagnosticList
+			if !errors.As(err, &d) {
+				l.WithError(err).Error("could not generate code")
+				return
 			}
 
-			models = append(models, model)
-
-			for _, g := range generatorList {
-				g, ok := g.(generatorForModel)
-				if !ok {
-					continue
-				}
+			printDiagnostics(l, d)
 
-				l := l.WithFields(logrus.Fields{
-					"mode":      "singular",
-					"generator": g.Name(),
-				})
-
-				for _, w := range g.Model(model) {
-					l := l.WithField("writer", w.Name())
-
-					if !flagFilters.match(model.Singular, g.Name()+"/"+w.Name()) {
-						l.Debug("skipping writer due to not matching filter(s)")
-						continue
-					}
-
-					if err := executeWriter(l, w); err != nil {
-						l.WithError(err).Fatal("could not execute writer")
-					}
-				}
-			}
+			l.WithField("diagnostics", len(d)).Error("problems found in model(s)")
+		}); err != nil {
+			l.WithError(err).Fatal("could not watch for changes")
 		}
 
-		for _, g := range generatorList {
-			g, ok := g.(generatorForModels)
-			if !ok {
-				continue
-			}
-
-			l := l.WithFields(logrus.Fields{
-				"mode":      "aggregated",
-				"generator": g.Name(),
-			})
+		return
+	}
 
-			for _, w := range g.Models(models) {
-				l := l.WithField("writer", w.Name())
+	res, err := apigen.Generate(ctx, *cfg)
+	if err != nil {
+		fatal(l, err)
+	}
 
-				if !flagFilters.match("_", g.Name()+"/"+w.Name()) {
-					l.Debug("skipping writer due to not matching filter(s)")
-					continue
-				}
+	printDiagnostics(l, nil)
 
-				if err := executeWriter(l, w); err != nil {
-					l.WithError(err).Fatal("could not execute writer")
-				}
-			}
-		}
+	if flagCheck && len(res.OutOfDate) > 0 {
+		l.WithField("files", len(res.OutOfDate)).Fatal("generated files are out of date")
 	}
 }
 
-func executeWriter(l *logrus.Entry, w writer) error {
-	filename := w.File()
-
-	l = l.WithField("output", filename)
-
-	l.Info("executing writer")
+// loadConfig loads the config file and applies the command line flags to it.
+func loadConfig(l *logrus.Entry) (*apigen.Config, error) {
+	cfg, configFile, err := apigen.LoadConfig(flagConfig)
+	if err != nil {
+		return nil, err
+	}
+	if configFile != "" {
+		l.WithField("config", configFile).Debug("loaded config")
+	}
 
-	if !flagDry {
-		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
-			l.WithError(err).Fatal("could not prepare target directory")
+	// output directories given on the command line are relative to the
+	// working directory, rather than to the go directory like those in the
+	// config file
+	if flagJSDir != "" {
+		if cfg.JSDir, err = filepath.Abs(flagJSDir); err != nil {
+			return nil, fmt.Errorf("couldn't resolve js directory: %w", err)
 		}
 	}
+	if flagFlowDir != "" {
+		if cfg.FlowDir, err = filepath.Abs(flagFlowDir); err != nil {
+			return nil, fmt.Errorf("couldn't resolve flow directory: %w", err)
+		}
+	}
+	if flagTemplateDir != "" {
+		cfg.TemplateDir = flagTemplateDir
+	}
+
+	cfg.Patterns = flag.Args()
+	cfg.GoDir = flagGoDir
+	cfg.Filters = flagFilters
+	cfg.Dry = flagDry
+	cfg.Check = flagCheck
+	cfg.Prune = flagPrune
+	cfg.Force = flagForce
+	cfg.DisableFormatting = flagDisableFormatting
+	cfg.AllowSourceErrors = flagAllowSourceErrors
+	cfg.Jobs = flagJobs
+	cfg.Logger = logrus.StandardLogger()
+	cfg.Diffs = os.Stdout
+	cfg.Progress = os.Stderr
+
+	return cfg, nil
+}
 
-	buf := bytes.NewBuffer(nil)
-
-	if w, ok := w.(writerForGo); ok {
-		logTime(l, "execute go header template", func() {
-			if err := headerTemplate.Execute(buf, struct {
-				PackageName string
-				Imports     []string
-			}{w.PackageName(), w.Imports()}); err != nil {
-				l.WithError(err).Fatal("could not write go header")
-			}
-		})
+// fatal reports an error from apigen and exits, printing the diagnostics in
+// the requested format if there were problems with the models.
+func fatal(l *logrus.Entry, err error) {
+	var d apigen.DiagnosticList
+	if !errors.As(err, &d) {
+		l.WithError(err).Fatal("could not generate code")
 	}
 
-	logTime(l, "generate code", func() {
-		if err := w.Write(buf); err != nil {
-			l.WithError(err).Fatal("could not generate code")
-		}
-	})
+	printDiagnostics(l, d)
 
-	nice := buf.Bytes()
+	l.WithField("diagnostics", len(d)).Fatal("problems found in model(s)")
+}
 
-	if w.Language() == "go" && !flagDisableFormatting {
-		logTime(l, "format go code", func() {
-			d, err := imports.Process(filename, nice, nil)
-			if err != nil {
-				l.WithError(err).Fatal("could not format go code")
-			}
-			nice = d
-		})
+// printDiagnostics prints the diagnostics to stderr as text, or to stdout as
+// JSON. In JSON mode, something is always printed so that tools reading the
+// output don't have to handle there being nothing.
+func printDiagnostics(l *logrus.Entry, d apigen.DiagnosticList) {
+	if len(d) == 0 && flagDiagnostics != "json" {
+		return
 	}
 
-	if !flagDry {
-		logTime(l, "write file", func() {
-			if err := ioutil.WriteFile(filename, nice, 0644); err != nil {
-				l.WithError(err).Fatal("could not write output")
-			}
-		})
+	out := io.Writer(os.Stderr)
+	if flagDiagnostics == "json" {
+		out = os.Stdout
 	}
 
-	return nil
+	if err := d.Print(out, flagDiagnostics); err != nil {
+		l.WithError(err).Fatal("could not print diagnostics")
+	}
 }